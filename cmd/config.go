@@ -0,0 +1,104 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"k8s.io/klog/v2"
+)
+
+const configFileName = ".pluto.yaml"
+
+// findConfigFile looks for a config file in dir and each of its parents, up to the
+// root of the git repository that dir is in. It returns an empty string if there is
+// no config file.
+func findConfigFile(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		path := filepath.Join(dir, configFileName)
+		info, err := os.Stat(path)
+		if err == nil && !info.IsDir() {
+			return path, nil
+		}
+		// .git is a file in worktrees and submodules
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return "", nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// readConfigFile reads the config file into v. Relative paths to an
+// additional versions file are relative to the config file.
+func readConfigFile(v *viper.Viper, path string) error {
+	v.SetConfigFile(path)
+	v.SetConfigType("yaml")
+	if err := v.ReadInConfig(); err != nil {
+		return fmt.Errorf("error reading config file %s: %w", path, err)
+	}
+	klog.V(2).Infof("using config file %s", path)
+
+	if v.InConfig("additional-versions") {
+		if _, found := os.LookupEnv(envPrefix + "_ADDITIONAL_VERSIONS"); !found {
+			file := v.GetString("additional-versions")
+			if file != "" && !filepath.IsAbs(file) {
+				v.Set("additional-versions", filepath.Join(filepath.Dir(path), file))
+			}
+		}
+	}
+	return nil
+}
+
+// setFlagFromConfig sets a flag from a value in the environment or the config file.
+// Values from the config file can be lists or maps, which are converted to the
+// format of the flag.
+func setFlagFromConfig(flags *pflag.FlagSet, f *pflag.Flag, val interface{}) error {
+	switch typed := val.(type) {
+	case []interface{}, []string:
+		values := cast.ToStringSlice(typed)
+		if sliceValue, ok := f.Value.(pflag.SliceValue); ok {
+			if err := sliceValue.Replace(values); err != nil {
+				return err
+			}
+			f.Changed = true
+			return nil
+		}
+		return flags.Set(f.Name, strings.Join(values, ","))
+	case map[string]interface{}:
+		pairs := make([]string, 0, len(typed))
+		for k, v := range typed {
+			pairs = append(pairs, fmt.Sprintf("%s=%v", k, v))
+		}
+		sort.Strings(pairs)
+		return flags.Set(f.Name, strings.Join(pairs, ","))
+	default:
+		return flags.Set(f.Name, fmt.Sprintf("%v", val))
+	}
+}
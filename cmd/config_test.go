@@ -0,0 +1,256 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeFiles creates the files in root. Names that end in a slash are directories.
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if strings.HasSuffix(name, "/") {
+			assert.NoError(t, os.MkdirAll(path, 0755))
+			continue
+		}
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func Test_findConfigFile(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		dir   string
+		want  string
+	}{
+		{
+			name: "in the directory",
+			files: map[string]string{
+				"repo/.git/":       "",
+				"repo/.pluto.yaml": "",
+			},
+			dir:  "repo",
+			want: "repo/.pluto.yaml",
+		},
+		{
+			name: "in a parent directory",
+			files: map[string]string{
+				"repo/.git/":         "",
+				"repo/.pluto.yaml":   "",
+				"repo/charts/a/b/c/": "",
+			},
+			dir:  "repo/charts/a/b/c",
+			want: "repo/.pluto.yaml",
+		},
+		{
+			name: "nearest file wins",
+			files: map[string]string{
+				"repo/.git/":              "",
+				"repo/.pluto.yaml":        "",
+				"repo/charts/.pluto.yaml": "",
+				"repo/charts/a/":          "",
+			},
+			dir:  "repo/charts/a",
+			want: "repo/charts/.pluto.yaml",
+		},
+		{
+			name: "stops at the repository root",
+			files: map[string]string{
+				".pluto.yaml":  "",
+				"repo/.git/":   "",
+				"repo/charts/": "",
+			},
+			dir: "repo/charts",
+		},
+		{
+			name: "stops at a worktree root",
+			files: map[string]string{
+				".pluto.yaml":   "",
+				"worktree/.git": "gitdir: ../repo/.git/worktrees/worktree",
+				"worktree/a/":   "",
+			},
+			dir: "worktree/a",
+		},
+		{
+			name: "directory named like the config file",
+			files: map[string]string{
+				"repo/.git/":        "",
+				"repo/.pluto.yaml/": "",
+			},
+			dir: "repo",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeFiles(t, root, tt.files)
+			got, err := findConfigFile(filepath.Join(root, tt.dir))
+			assert.NoError(t, err)
+			want := tt.want
+			if want != "" {
+				want = filepath.Join(root, want)
+			}
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func Test_readConfigFile(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		env    string
+		// want is relative to the directory of the config file, unless it is absolute
+		// or it comes from the environment
+		want string
+	}{
+		{
+			name:   "relative to the config file",
+			config: "additional-versions: versions/extra.yaml\n",
+			want:   "versions/extra.yaml",
+		},
+		{
+			name:   "parent of the config file",
+			config: "additional-versions: ../extra.yaml\n",
+			want:   "../extra.yaml",
+		},
+		{
+			name:   "absolute",
+			config: "additional-versions: /etc/pluto/extra.yaml\n",
+			want:   "/etc/pluto/extra.yaml",
+		},
+		{
+			name:   "environment wins",
+			config: "additional-versions: versions/extra.yaml\n",
+			env:    "from-env.yaml",
+			want:   "from-env.yaml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := filepath.Join(t.TempDir(), "repo")
+			path := filepath.Join(root, configFileName)
+			writeFiles(t, root, map[string]string{configFileName: tt.config})
+			if tt.env != "" {
+				t.Setenv(envPrefix+"_ADDITIONAL_VERSIONS", tt.env)
+			}
+
+			// bindFlags binds the environment variables like this
+			v := viper.New()
+			assert.NoError(t, v.BindEnv("additional-versions", envPrefix+"_ADDITIONAL_VERSIONS"))
+			assert.NoError(t, readConfigFile(v, path))
+
+			want := tt.want
+			if tt.env == "" && !filepath.IsAbs(want) {
+				want = filepath.Join(root, want)
+			}
+			assert.Equal(t, want, v.GetString("additional-versions"))
+		})
+	}
+
+	err := readConfigFile(viper.New(), filepath.Join(t.TempDir(), configFileName))
+	assert.Error(t, err)
+}
+
+func Test_bindFlags(t *testing.T) {
+	tests := []struct {
+		name           string
+		config         string
+		args           []string
+		wantTargets    map[string]string
+		wantComponents []string
+		wantOutput     string
+		wantIgnore     bool
+	}{
+		{
+			name:        "map into a map flag",
+			config:      "target-versions:\n  k8s: v1.25.0\n  istio: v1.6.0\n",
+			wantTargets: map[string]string{"k8s": "v1.25.0", "istio": "v1.6.0"},
+		},
+		{
+			name:           "list into a slice flag",
+			config:         "components:\n  - k8s\n  - cert-manager\n",
+			wantComponents: []string{"k8s", "cert-manager"},
+		},
+		{
+			name:           "comma separated string into a slice flag",
+			config:         "components: k8s,istio\n",
+			wantComponents: []string{"k8s", "istio"},
+		},
+		{
+			name:       "scalars",
+			config:     "output: wide\nignore-deprecations: true\n",
+			wantOutput: "wide",
+			wantIgnore: true,
+		},
+		{
+			name:           "command line wins",
+			config:         "target-versions:\n  k8s: v1.25.0\ncomponents:\n  - k8s\n  - istio\noutput: wide\nignore-deprecations: true\n",
+			args:           []string{"--target-versions", "k8s=v1.29.0", "--components", "cert-manager", "-o", "json", "--ignore-deprecations=false"},
+			wantTargets:    map[string]string{"k8s": "v1.29.0"},
+			wantComponents: []string{"cert-manager"},
+			wantOutput:     "json",
+		},
+		{
+			name:        "command line wins for some flags",
+			config:      "target-versions:\n  k8s: v1.25.0\noutput: wide\n",
+			args:        []string{"-o", "json"},
+			wantTargets: map[string]string{"k8s": "v1.25.0"},
+			wantOutput:  "json",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				targets    map[string]string
+				components []string
+				output     string
+				ignore     bool
+			)
+			cmd := &cobra.Command{Use: "test"}
+			cmd.Flags().StringToStringVarP(&targets, "target-versions", "t", nil, "")
+			cmd.Flags().StringSliceVar(&components, "components", nil, "")
+			cmd.Flags().StringVarP(&output, "output", "o", "", "")
+			cmd.Flags().BoolVar(&ignore, "ignore-deprecations", false, "")
+			assert.NoError(t, cmd.Flags().Parse(tt.args))
+
+			path := filepath.Join(t.TempDir(), configFileName)
+			assert.NoError(t, os.WriteFile(path, []byte(tt.config), 0644))
+			v := viper.New()
+			assert.NoError(t, readConfigFile(v, path))
+			bindFlags(cmd, v)
+
+			if tt.wantTargets == nil {
+				assert.Empty(t, targets)
+			} else {
+				assert.Equal(t, tt.wantTargets, targets)
+			}
+			assert.Equal(t, tt.wantComponents, components)
+			assert.Equal(t, tt.wantOutput, output)
+			assert.Equal(t, tt.wantIgnore, ignore)
+		})
+	}
+}
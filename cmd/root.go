@@ -29,17 +29,29 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
+	"os/signal"
+	"runtime"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/fairwindsops/pluto/v5/pkg/api"
 	discoveryapi "github.com/fairwindsops/pluto/v5/pkg/discovery-api"
 	"github.com/fairwindsops/pluto/v5/pkg/finder"
 	"github.com/fairwindsops/pluto/v5/pkg/helm"
+	"github.com/fairwindsops/pluto/v5/pkg/kube"
+	"github.com/fairwindsops/pluto/v5/pkg/metrics"
+	"github.com/fairwindsops/pluto/v5/pkg/pluto"
+	"github.com/fairwindsops/pluto/v5/pkg/webhook"
 	"golang.org/x/mod/semver"
+	"gopkg.in/yaml.v3"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -57,6 +69,7 @@ var (
 	ignoreDeprecations            bool
 	ignoreRemovals                bool
 	ignoreUnavailableReplacements bool
+	ignoreTooNew                  bool
 	namespace                     string
 	apiInstance                   *api.Instance
 	targetVersions                map[string]string
@@ -67,53 +80,143 @@ var (
 	noHeaders                     bool
 	exitCode                      int
 	kubeConfigPath                string
+	workers                       int
+	includePatterns               []string
+	excludePatterns               []string
+	dryRun                        bool
+	chartOptions                  helm.ChartOptions
+	targetVersionsFromCluster     bool
+	upgradeTo                     string
+	configPath                    string
+	baselinePath                  string
+	writeBaseline                 bool
+	planFrom                      string
+	planTo                        string
+	fromCluster                   bool
+	generateComponent             string
+	generateVersion               string
+	listenAddress                 string
+	scanInterval                  time.Duration
+	webhookAddress                string
+	tlsCertFile                   string
+	tlsKeyFile                    string
+	warnOnly                      bool
+	denyDeprecated                bool
+	kubeContexts                  []string
+	allContexts                   bool
+	timeout                       time.Duration
+	interrupted                   bool
 )
 
 const (
 	envPrefix = "PLUTO"
 )
 
-var outputOptions = []string{
-	"json",
-	"yaml",
-	"normal",
-	"wide",
-	"custom",
-	"markdown",
-	"csv",
-}
-
 func init() {
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "The path to a config file. If blank, .pluto.yaml is searched for in the scanned directory and its parents.")
 	rootCmd.PersistentFlags().BoolVar(&ignoreDeprecations, "ignore-deprecations", false, "Ignore the default behavior to exit 2 if deprecated apiVersions are found.")
 	rootCmd.PersistentFlags().BoolVar(&ignoreRemovals, "ignore-removals", false, "Ignore the default behavior to exit 3 if removed apiVersions are found.")
 	rootCmd.PersistentFlags().BoolVar(&ignoreUnavailableReplacements, "ignore-unavailable-replacements", false, "Ignore the default behavior to exit 4 if deprecated but unavailable apiVersions are found.")
+	rootCmd.PersistentFlags().BoolVar(&ignoreTooNew, "ignore-too-new", false, "Ignore the default behavior to exit 5 if apiVersions that are not available yet in the target version are found.")
 	rootCmd.PersistentFlags().BoolVarP(&onlyShowRemoved, "only-show-removed", "r", false, "Only display the apiVersions that have been removed in the target version.")
 	rootCmd.PersistentFlags().BoolVarP(&noHeaders, "no-headers", "H", false, "When using the default or custom-column output format, don't print headers (default print headers).")
 	rootCmd.PersistentFlags().StringVarP(&additionalVersionsFile, "additional-versions", "f", "", "Additional deprecated versions file to add to the list. Cannot contain any existing versions")
 	rootCmd.PersistentFlags().StringToStringVarP(&targetVersions, "target-versions", "t", targetVersions, "A map of targetVersions to use. This flag supersedes all defaults in version files.")
-	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "normal", "The output format to use. (normal|wide|custom|json|yaml|markdown|csv)")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "normal", "The output format to use. (normal|wide|custom|json|yaml|markdown|csv|sarif|junit)")
 	rootCmd.PersistentFlags().StringSliceVar(&customColumns, "columns", nil, "A list of columns to print. Mandatory when using --output custom, optional with --output markdown")
+	rootCmd.PersistentFlags().StringVar(&baselinePath, "baseline", "", "A baseline file of known findings. Findings in the baseline are listed separately and do not affect the exit code.")
+	rootCmd.PersistentFlags().BoolVar(&writeBaseline, "write-baseline", false, "Write all current findings to the --baseline file.")
 	rootCmd.PersistentFlags().StringSliceVar(&componentsFromUser, "components", nil, "A list of components to run checks for. If nil, will check for all found in versions.")
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 0, "The maximum time to spend on calls to the cluster, like 5m. If 0, there is no timeout.")
 
 	rootCmd.AddCommand(detectFilesCmd)
 	detectFilesCmd.PersistentFlags().StringVarP(&directory, "directory", "d", "", "The directory to scan. If blank, defaults to current working dir.")
+	detectFilesCmd.PersistentFlags().IntVar(&workers, "workers", runtime.NumCPU(), "The number of files to scan concurrently.")
+	detectFilesCmd.PersistentFlags().StringSliceVar(&includePatterns, "include", nil, "A list of gitignore-style patterns. If set, only matching files are scanned.")
+	detectFilesCmd.PersistentFlags().StringSliceVar(&excludePatterns, "exclude", nil, "A list of gitignore-style patterns for files and directories to skip. Applied after any .plutoignore files.")
+
+	rootCmd.AddCommand(detectKustomizeCmd)
+	detectKustomizeCmd.PersistentFlags().StringVarP(&directory, "directory", "d", "", "The directory to search for kustomizations. If blank, defaults to current working dir.")
+
+	rootCmd.AddCommand(detectAuditLogCmd)
+
+	rootCmd.AddCommand(fixCmd)
+	fixCmd.PersistentFlags().StringVarP(&directory, "directory", "d", "", "The directory to fix. If blank, defaults to current working dir.")
+	fixCmd.PersistentFlags().StringSliceVar(&includePatterns, "include", nil, "A list of gitignore-style patterns. If set, only matching files are fixed.")
+	fixCmd.PersistentFlags().StringSliceVar(&excludePatterns, "exclude", nil, "A list of gitignore-style patterns for files and directories to skip. Applied after any .plutoignore files.")
+	fixCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Print a unified diff of the changes instead of writing them.")
+
+	rootCmd.AddCommand(planCmd)
+	planCmd.PersistentFlags().StringVar(&planFrom, "from", "", "The Kubernetes version to upgrade from, like v1.22.")
+	planCmd.PersistentFlags().StringVar(&planTo, "to", "", "The Kubernetes version to upgrade to, like v1.29.")
+	planCmd.PersistentFlags().StringVarP(&directory, "directory", "d", "", "The directory to scan. If blank, defaults to current working dir.")
+	planCmd.PersistentFlags().IntVar(&workers, "workers", runtime.NumCPU(), "The number of files to scan concurrently.")
+	planCmd.PersistentFlags().StringSliceVar(&includePatterns, "include", nil, "A list of gitignore-style patterns. If set, only matching files are scanned.")
+	planCmd.PersistentFlags().StringSliceVar(&excludePatterns, "exclude", nil, "A list of gitignore-style patterns for files and directories to skip. Applied after any .plutoignore files.")
+	_ = planCmd.MarkPersistentFlagRequired("from")
+	_ = planCmd.MarkPersistentFlagRequired("to")
 
 	rootCmd.AddCommand(detectHelmCmd)
 	detectHelmCmd.PersistentFlags().StringVarP(&kubeConfigPath, "kubeconfig", "", "", "The path to the kubeconfig file to use. If blank, defaults to current kubeconfig.")
 	detectHelmCmd.PersistentFlags().StringVarP(&namespace, "namespace", "n", "", "Only detect releases in a specific namespace.")
 	detectHelmCmd.PersistentFlags().StringVar(&kubeContext, "kube-context", "", "The kube context to use. If blank, defaults to current context.")
+	detectHelmCmd.PersistentFlags().StringSliceVar(&kubeContexts, "contexts", nil, "A list of kube contexts to scan concurrently.")
+	detectHelmCmd.PersistentFlags().BoolVar(&allContexts, "all-contexts", false, "Scan every context in the kubeconfig concurrently.")
+	detectHelmCmd.PersistentFlags().BoolVar(&targetVersionsFromCluster, "target-versions-from-cluster", false, "Use the version of the cluster as the k8s target version.")
+	detectHelmCmd.PersistentFlags().StringVar(&upgradeTo, "upgrade-to", "", "A number of minor versions to add to the cluster version, like +1. Requires --target-versions-from-cluster.")
+
+	rootCmd.AddCommand(detectHelmChartCmd)
+	detectHelmChartCmd.PersistentFlags().StringVarP(&namespace, "namespace", "n", "", "The namespace used when rendering the chart. If blank, defaults to default.")
+	detectHelmChartCmd.PersistentFlags().StringVar(&chartOptions.ReleaseName, "release-name", "release-name", "The release name used when rendering the chart.")
+	detectHelmChartCmd.PersistentFlags().StringSliceVar(&chartOptions.ValueFiles, "values", nil, "A list of values files or URLs to use when rendering the chart.")
+	detectHelmChartCmd.PersistentFlags().StringArrayVar(&chartOptions.Values, "set", nil, "Set values on the command line (can specify multiple or separate values with commas: key1=val1,key2=val2)")
+	detectHelmChartCmd.PersistentFlags().StringVar(&chartOptions.KubeVersion, "kube-version", "", "The Kubernetes version used for Capabilities.KubeVersion. If blank, defaults to the k8s target version.")
+	detectHelmChartCmd.PersistentFlags().StringSliceVar(&chartOptions.APIVersions, "api-versions", nil, "Kubernetes api versions used for Capabilities.APIVersions.")
 
 	rootCmd.AddCommand(detectApiResourceCmd)
 	detectApiResourceCmd.PersistentFlags().StringVarP(&kubeConfigPath, "kubeconfig", "", "", "The path to the kubeconfig file to use. If blank, defaults to current kubeconfig.")
 	detectApiResourceCmd.PersistentFlags().StringVarP(&namespace, "namespace", "n", "", "Only detect resources in a specific namespace.")
 	detectApiResourceCmd.PersistentFlags().StringVar(&kubeContext, "kube-context", "", "The kube context to use. If blank, defaults to current context.")
+	detectApiResourceCmd.PersistentFlags().StringSliceVar(&kubeContexts, "contexts", nil, "A list of kube contexts to scan concurrently.")
+	detectApiResourceCmd.PersistentFlags().BoolVar(&allContexts, "all-contexts", false, "Scan every context in the kubeconfig concurrently.")
+	detectApiResourceCmd.PersistentFlags().BoolVar(&targetVersionsFromCluster, "target-versions-from-cluster", false, "Use the version of the cluster as the k8s target version.")
+	detectApiResourceCmd.PersistentFlags().StringVar(&upgradeTo, "upgrade-to", "", "A number of minor versions to add to the cluster version, like +1. Requires --target-versions-from-cluster.")
 
 	rootCmd.AddCommand(detectAllInClusterCmd)
 	detectAllInClusterCmd.PersistentFlags().StringVarP(&kubeConfigPath, "kubeconfig", "", "", "The path to the kubeconfig file to use. If blank, defaults to current kubeconfig.")
 	detectAllInClusterCmd.PersistentFlags().StringVarP(&namespace, "namespace", "n", "", "Only detect resources in a specific namespace.")
 	detectAllInClusterCmd.PersistentFlags().StringVar(&kubeContext, "kube-context", "", "The kube context to use. If blank, defaults to current context.")
+	detectAllInClusterCmd.PersistentFlags().StringSliceVar(&kubeContexts, "contexts", nil, "A list of kube contexts to scan concurrently.")
+	detectAllInClusterCmd.PersistentFlags().BoolVar(&allContexts, "all-contexts", false, "Scan every context in the kubeconfig concurrently.")
+	detectAllInClusterCmd.PersistentFlags().BoolVar(&targetVersionsFromCluster, "target-versions-from-cluster", false, "Use the version of the cluster as the k8s target version.")
+	detectAllInClusterCmd.PersistentFlags().StringVar(&upgradeTo, "upgrade-to", "", "A number of minor versions to add to the cluster version, like +1. Requires --target-versions-from-cluster.")
+
+	rootCmd.AddCommand(serveMetricsCmd)
+	serveMetricsCmd.PersistentFlags().StringVarP(&kubeConfigPath, "kubeconfig", "", "", "The path to the kubeconfig file to use. If blank, defaults to current kubeconfig.")
+	serveMetricsCmd.PersistentFlags().StringVarP(&namespace, "namespace", "n", "", "Only detect releases and resources in a specific namespace.")
+	serveMetricsCmd.PersistentFlags().StringVar(&kubeContext, "kube-context", "", "The kube context to use. If blank, defaults to current context.")
+	serveMetricsCmd.PersistentFlags().StringVar(&listenAddress, "listen-address", ":8080", "The address to serve /metrics on.")
+	serveMetricsCmd.PersistentFlags().DurationVar(&scanInterval, "interval", time.Hour, "How often to scan the cluster.")
+
+	rootCmd.AddCommand(webhookCmd)
+	webhookCmd.PersistentFlags().StringVar(&webhookAddress, "listen-address", ":8443", "The address to serve the webhook on.")
+	webhookCmd.PersistentFlags().StringVar(&tlsCertFile, "tls-cert-file", "", "The TLS certificate to serve the webhook with. If blank, the webhook is served over plain HTTP.")
+	webhookCmd.PersistentFlags().StringVar(&tlsKeyFile, "tls-private-key-file", "", "The private key for --tls-cert-file.")
+	webhookCmd.PersistentFlags().BoolVar(&warnOnly, "warn-only", false, "Allow objects with removed apiVersions, with a warning.")
+	webhookCmd.PersistentFlags().BoolVar(&denyDeprecated, "deny-deprecated", false, "Deny objects with deprecated apiVersions too, instead of warning.")
 
 	rootCmd.AddCommand(listVersionsCmd)
+
+	rootCmd.AddCommand(validateVersionsCmd)
+
+	rootCmd.AddCommand(generateVersionsCmd)
+	generateVersionsCmd.PersistentFlags().StringVarP(&directory, "directory", "d", "", "The directory to read CustomResourceDefinitions from. If blank, defaults to current working dir.")
+	generateVersionsCmd.PersistentFlags().BoolVar(&fromCluster, "from-cluster", false, "Read the CustomResourceDefinitions from the cluster instead of a directory.")
+	generateVersionsCmd.PersistentFlags().StringVarP(&kubeConfigPath, "kubeconfig", "", "", "The path to the kubeconfig file to use. If blank, defaults to current kubeconfig.")
+	generateVersionsCmd.PersistentFlags().StringVar(&kubeContext, "kube-context", "", "The kube context to use. If blank, defaults to current context.")
+	generateVersionsCmd.PersistentFlags().StringVar(&generateComponent, "component", "k8s", "The component of the generated versions.")
+	generateVersionsCmd.PersistentFlags().StringVar(&generateVersion, "version", "", "The version of the component that the generated versions are deprecated or removed in, like v1.2.0.")
+	_ = generateVersionsCmd.MarkPersistentFlagRequired("version")
 	rootCmd.AddCommand(detectCmd)
 
 	klog.InitFlags(nil)
@@ -125,6 +228,25 @@ func initializeConfig(cmd *cobra.Command) error {
 	v.SetEnvPrefix(envPrefix)
 	v.AutomaticEnv()
 
+	configFile := configPath
+	if configFile == "" {
+		// search from the directory being scanned, or the working directory
+		searchDir := directory
+		if searchDir == "" {
+			searchDir = "."
+		}
+		var err error
+		configFile, err = findConfigFile(searchDir)
+		if err != nil {
+			return err
+		}
+	}
+	if configFile != "" {
+		if err := readConfigFile(v, configFile); err != nil {
+			return err
+		}
+	}
+
 	bindFlags(cmd, v)
 
 	return nil
@@ -143,7 +265,7 @@ func bindFlags(cmd *cobra.Command, v *viper.Viper) {
 
 		if !f.Changed && v.IsSet(f.Name) {
 			val := v.Get(f.Name)
-			err := cmd.Flags().Set(f.Name, fmt.Sprintf("%v", val))
+			err := setFlagFromConfig(cmd.Flags(), f, val)
 			if err != nil {
 				klog.Errorf("error setting flag %s to %v: %v", f.Name, val, err)
 				return
@@ -165,6 +287,20 @@ var rootCmd = &cobra.Command{
 		os.Exit(1)
 	},
 	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		if writeBaseline && strings.HasPrefix(cmd.Name(), "detect") {
+			if interrupted {
+				fmt.Println("Error writing baseline: the scan did not finish")
+				os.Exit(1)
+			}
+			baseline := api.NewBaseline(append(append([]*api.Output{}, apiInstance.Outputs...), apiInstance.Baselined...))
+			err := baseline.Write(baselinePath)
+			if err != nil {
+				fmt.Println("Error writing baseline:", err)
+				os.Exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "Wrote %d findings to baseline %s\n", len(baseline.Findings), baselinePath)
+			exitCode = 0
+		}
 		klog.V(5).Infof("exiting with code %d", exitCode)
 		os.Exit(exitCode)
 	},
@@ -175,8 +311,8 @@ var rootCmd = &cobra.Command{
 		}
 
 		//verify output option
-		if !api.StringInSlice(outputFormat, outputOptions) {
-			return fmt.Errorf("--output must be one of %v", outputOptions)
+		if !api.StringInSlice(outputFormat, pluto.OutputFormats) {
+			return fmt.Errorf("--output must be one of %v", pluto.OutputFormats)
 		}
 
 		if outputFormat == "custom" {
@@ -200,94 +336,74 @@ var rootCmd = &cobra.Command{
 			}
 		}
 
-		defaultVersions, defaultTargetVersions, err := api.GetDefaultVersionList(versionFileData)
-		if err != nil {
-			return err
-		}
-		var deprecatedVersionList []api.Version
+		var additionalVersions []byte
 		if additionalVersionsFile != "" {
 			klog.V(2).Infof("looking for versions file: %s", additionalVersionsFile)
-			data, err := os.ReadFile(additionalVersionsFile)
+			additionalVersions, err = os.ReadFile(additionalVersionsFile)
 			if err != nil {
 				return err
 			}
-			additionalVersions, additionalTargetVersions, err := api.UnMarshalVersions(data)
-			if err != nil {
-				return err
-			}
-			deprecatedVersionList, err = api.CombineAdditionalVersions(additionalVersions, defaultVersions)
-			if err != nil {
-				return err
-			}
-			for c, v := range additionalTargetVersions {
-				klog.V(2).Infof("received target version from config: %s %s", c, v)
-				// Only add them to default target versions if they do not supersed any previously included
-				// This prevents overwriting the internal defaults
-				if _, found := defaultTargetVersions[c]; !found {
-					defaultTargetVersions[c] = v
-				}
-			}
 		} else {
 			klog.V(2).Info("no additional versions needed")
-			deprecatedVersionList = defaultVersions
-		}
-
-		// From the compiled list of deprecations and the components flag, build a component list
-		var componentList []string
-		for _, v := range deprecatedVersionList {
-			if !api.StringInSlice(v.Component, componentList) {
-				if componentsFromUser == nil {
-					// if the pass-in components are nil, then we use the versions in the main list
-					componentList = append(componentList, v.Component)
-				} else {
-					if api.StringInSlice(v.Component, componentsFromUser) {
-						// if a component list was passed, make sure that it is in the
-						// the list of versions before adding it to the final list
-						componentList = append(componentList, v.Component)
-					}
-				}
+		}
+
+		if allContexts && len(kubeContexts) > 0 {
+			return fmt.Errorf("--all-contexts cannot be used with --contexts")
+		}
+		if allContexts || len(kubeContexts) > 0 {
+			if kubeContext != "" {
+				return fmt.Errorf("--kube-context cannot be used with --contexts or --all-contexts")
+			}
+			if targetVersionsFromCluster {
+				return fmt.Errorf("--target-versions-from-cluster cannot be used with --contexts or --all-contexts")
 			}
 		}
-		if len(componentList) < 1 {
-			return fmt.Errorf("cannot find deprecations for zero components")
+		if upgradeTo != "" && !targetVersionsFromCluster {
+			return fmt.Errorf("--upgrade-to requires --target-versions-from-cluster")
 		}
-
-		// Combine the default target versions and the ones that are passed. Ones that are passed in take precedence
-		if targetVersions != nil {
-			for k, v := range defaultTargetVersions {
-				if _, found := targetVersions[k]; !found {
-					klog.V(2).Infof("assuming default targetVersion %s %s", k, v)
-					targetVersions[k] = v
-				}
+		if targetVersionsFromCluster {
+			if _, found := targetVersions["k8s"]; found {
+				return fmt.Errorf("--target-versions-from-cluster cannot be used with a k8s target version")
 			}
-		} else {
-			targetVersions = defaultTargetVersions
 		}
 
-		// verify that we have valid target versions for all components
-		for component, version := range targetVersions {
-			if !semver.IsValid(version) {
-				return fmt.Errorf("you must use valid semver for all target versions with a leading 'v' - got %s %s", component, version)
-			}
+		var baseline *api.Baseline
+		if writeBaseline && baselinePath == "" {
+			return fmt.Errorf("--write-baseline requires --baseline")
 		}
-		for _, c := range componentList {
-			if _, found := targetVersions[c]; !found {
-				return fmt.Errorf("you must pass a targetVersion for every component in the list - missing component: %s", c)
+		if baselinePath != "" && !writeBaseline {
+			baseline, err = api.ReadBaseline(baselinePath)
+			if err != nil {
+				return err
 			}
 		}
 
 		// this apiInstance will be used by all detection methods
-		apiInstance = &api.Instance{
+		apiInstance, err = pluto.NewInstance(pluto.Options{
+			VersionsFile:                  versionFileData,
+			AdditionalVersions:            additionalVersions,
 			TargetVersions:                targetVersions,
-			OutputFormat:                  outputFormat,
-			CustomColumns:                 customColumns,
+			Components:                    componentsFromUser,
+			Baseline:                      baseline,
 			IgnoreDeprecations:            ignoreDeprecations,
 			IgnoreRemovals:                ignoreRemovals,
 			IgnoreUnavailableReplacements: ignoreUnavailableReplacements,
+			IgnoreTooNew:                  ignoreTooNew,
 			OnlyShowRemoved:               onlyShowRemoved,
+			OutputFormat:                  outputFormat,
+			Columns:                       customColumns,
 			NoHeaders:                     noHeaders,
-			DeprecatedVersions:            deprecatedVersionList,
-			Components:                    componentList,
+		})
+		if err != nil {
+			return err
+		}
+
+		if targetVersionsFromCluster {
+			clusterVersion, err := getTargetVersionFromCluster(cmd.Context())
+			if err != nil {
+				return err
+			}
+			apiInstance.TargetVersions["k8s"] = clusterVersion
 		}
 
 		return nil
@@ -299,8 +415,12 @@ var detectFilesCmd = &cobra.Command{
 	Short: "detect-files",
 	Long:  `Detect Kubernetes apiVersions in a directory.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		dir := finder.NewFinder(directory, apiInstance)
-		err := dir.FindVersions()
+		err := pluto.Directory{
+			Path:    directory,
+			Workers: workers,
+			Include: includePatterns,
+			Exclude: excludePatterns,
+		}.Scan(cmd.Context(), apiInstance)
 		if err != nil {
 			fmt.Println("Error running finder:", err)
 			os.Exit(1)
@@ -315,12 +435,133 @@ var detectFilesCmd = &cobra.Command{
 	},
 }
 
+var detectKustomizeCmd = &cobra.Command{
+	Use:   "detect-kustomize",
+	Short: "detect-kustomize",
+	Long:  `Detect Kubernetes apiVersions in the rendered output of kustomizations in a directory. Kustomizations that are used by another kustomization are not built on their own.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := pluto.Kustomize{Path: directory}.Scan(cmd.Context(), apiInstance)
+		if err != nil {
+			fmt.Println("Error running detect-kustomize:", err)
+			os.Exit(1)
+		}
+		err = apiInstance.DisplayOutput()
+		if err != nil {
+			fmt.Println("Error Parsing Output:", err)
+			os.Exit(1)
+		}
+		exitCode = apiInstance.GetReturnCode()
+		klog.V(5).Infof("exitCode: %d", exitCode)
+	},
+}
+
+var detectAuditLogCmd = &cobra.Command{
+	Use:   "detect-audit-log [file to check or -]",
+	Short: "Checks a Kubernetes audit log for requests to deprecated apiVersions.",
+	Long:  `Reads Kubernetes audit events as JSON lines and reports the users and user agents that made requests to deprecated apiVersions, with a count and the time of the last request. Use - to read from stdin.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := pluto.AuditLog{Path: args[0]}.Scan(cmd.Context(), apiInstance)
+		if err != nil {
+			fmt.Println("Error running detect-audit-log:", err)
+			os.Exit(1)
+		}
+		err = apiInstance.DisplayOutput()
+		if err != nil {
+			fmt.Println("Error Parsing Output:", err)
+			os.Exit(1)
+		}
+		exitCode = apiInstance.GetReturnCode()
+		klog.V(5).Infof("exitCode: %d", exitCode)
+	},
+}
+
+var fixCmd = &cobra.Command{
+	Use:   "fix",
+	Short: "Rewrites deprecated apiVersions in files to their replacements.",
+	Long: `Rewrites deprecated and removed apiVersions in a directory to their replacement apiVersions. Only resources whose replacement is available in the target version are changed.
+
+Only the apiVersion is changed. Some replacements also change the schema of the resource, like extensions/v1beta1 to networking.k8s.io/v1 for an Ingress, so check each rewritten resource against the schema of its new apiVersion before applying it.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := finder.NewFinder(directory, apiInstance)
+		if err != nil {
+			fmt.Println("Error running finder:", err)
+			os.Exit(1)
+		}
+		dir.Include = includePatterns
+		dir.Exclude = excludePatterns
+		fixes, err := dir.FixVersions()
+		if err != nil {
+			fmt.Println("Error running finder:", err)
+			os.Exit(1)
+		}
+		if len(fixes) == 0 {
+			fmt.Println("There were no resources found with apiVersions that can be fixed.")
+			return
+		}
+		for _, fix := range fixes {
+			if dryRun {
+				diff, err := fix.Diff()
+				if err != nil {
+					fmt.Println("Error creating diff:", err)
+					os.Exit(1)
+				}
+				fmt.Print(diff)
+			} else {
+				err := fix.Write()
+				if err != nil {
+					fmt.Println("Error writing file:", err)
+					os.Exit(1)
+				}
+				for _, output := range fix.Outputs {
+					fmt.Printf("%s:%d: %s %s %s -> %s\n", fix.Path, output.Line, output.APIVersion.Kind, output.Name, output.APIVersion.Name, output.APIVersion.ReplacementAPI)
+				}
+			}
+			for _, output := range fix.Outputs {
+				fmt.Fprintf(os.Stderr, "Warning: %s:%d: only the apiVersion of %s %s was changed - check it against the %s schema\n", fix.Path, output.Line, output.APIVersion.Kind, output.Name, output.APIVersion.ReplacementAPI)
+			}
+		}
+	},
+}
+
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Plans an upgrade one Kubernetes minor version at a time.",
+	Long:  `Scans a directory and shows, for each minor version between --from and --to, which resources must be migrated before upgrading to that version and whether their replacement is already available in the --from version.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := pluto.Directory{
+			Path:    directory,
+			Workers: workers,
+			Include: includePatterns,
+			Exclude: excludePatterns,
+		}.Scan(cmd.Context(), apiInstance)
+		if err != nil {
+			fmt.Println("Error running finder:", err)
+			os.Exit(1)
+		}
+		plan, err := apiInstance.Plan(planFrom, planTo)
+		if err != nil {
+			fmt.Println("Error creating plan:", err)
+			os.Exit(1)
+		}
+		err = apiInstance.DisplayPlan(os.Stdout, plan)
+		if err != nil {
+			fmt.Println("Error Parsing Output:", err)
+			os.Exit(1)
+		}
+	},
+}
+
 var detectHelmCmd = &cobra.Command{
 	Use:   "detect-helm",
 	Short: "detect-helm",
 	Long:  `Detect Kubernetes apiVersions in a helm release (in cluster)`,
 	Run: func(cmd *cobra.Command, args []string) {
-		err := detectHelm()
+		ctx, cancel := clusterContext(cmd.Context())
+		defer cancel()
+		result, err := detectInContexts(ctx, func(cluster pluto.Cluster) pluto.Source {
+			return pluto.HelmReleases{Cluster: cluster}
+		})
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
@@ -330,18 +571,47 @@ var detectHelmCmd = &cobra.Command{
 			fmt.Printf("Error Parsing Output: %v\n", err)
 			os.Exit(1)
 		}
-		exitCode = apiInstance.GetReturnCode()
+		exitCode = result.ReturnCode(apiInstance)
 		klog.V(5).Infof("exitCode: %d", exitCode)
 		return
 	},
 }
 
+var detectHelmChartCmd = &cobra.Command{
+	Use:   "detect-helm-chart <chart-dir|chart.tgz>",
+	Short: "detect-helm-chart",
+	Long:  `Detect Kubernetes apiVersions in a local helm chart. The chart is rendered with the helm template engine and each rendered template is checked.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := pluto.HelmChart{
+			Path:      args[0],
+			Namespace: namespace,
+			Options:   chartOptions,
+		}.Scan(cmd.Context(), apiInstance)
+		if err != nil {
+			fmt.Println("Error running detect-helm-chart:", err)
+			os.Exit(1)
+		}
+		err = apiInstance.DisplayOutput()
+		if err != nil {
+			fmt.Println("Error Parsing Output:", err)
+			os.Exit(1)
+		}
+		exitCode = apiInstance.GetReturnCode()
+		klog.V(5).Infof("exitCode: %d", exitCode)
+	},
+}
+
 var detectApiResourceCmd = &cobra.Command{
 	Use:   "detect-api-resources",
 	Short: "detect-api-resources",
 	Long:  `Detect Kubernetes apiVersions from an active cluster (using last-applied-configuration annotation)`,
 	Run: func(cmd *cobra.Command, args []string) {
-		err := detectAPIResources()
+		ctx, cancel := clusterContext(cmd.Context())
+		defer cancel()
+		result, err := detectInContexts(ctx, func(cluster pluto.Cluster) pluto.Source {
+			return pluto.APIResources{Cluster: cluster}
+		})
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
@@ -351,7 +621,7 @@ var detectApiResourceCmd = &cobra.Command{
 			fmt.Printf("Error Parsing Output: %v\n", err)
 			os.Exit(1)
 		}
-		exitCode = apiInstance.GetReturnCode()
+		exitCode = result.ReturnCode(apiInstance)
 		klog.V(5).Infof("exitCode: %d", exitCode)
 		return
 	},
@@ -362,29 +632,86 @@ var detectAllInClusterCmd = &cobra.Command{
 	Short: "run all in-cluster detections",
 	Long:  `Detect Kubernetes apiVersions from an active cluster using all available methods (Helm releases, using the last-applied-configuration annotation)`,
 	Run: func(cmd *cobra.Command, args []string) {
-		err := detectHelm()
+		ctx, cancel := clusterContext(cmd.Context())
+		defer cancel()
+		result, err := detectInContexts(ctx, inClusterSource)
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		}
-		klog.V(5).Infof("after running detect-helm, exit-code is %d, and there are %d output items", apiInstance.GetReturnCode(), len(apiInstance.Outputs))
-		err = detectAPIResources()
-		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
-		}
-		klog.V(5).Infof("after running detect-api-resources, exit-code is %d, and there are %d output items", apiInstance.GetReturnCode(), len(apiInstance.Outputs))
 
 		err = apiInstance.DisplayOutput()
 		if err != nil {
 			fmt.Printf("Error Parsing Output: %v\n", err)
 			os.Exit(1)
 		}
-		exitCode = apiInstance.GetReturnCode()
+		exitCode = result.ReturnCode(apiInstance)
 		klog.V(5).Infof("exitCode: %d", exitCode)
 	},
 }
 
+var serveMetricsCmd = &cobra.Command{
+	Use:   "serve-metrics",
+	Short: "Scans the cluster on an interval and serves the results as Prometheus metrics.",
+	Long:  `Runs the detect-all-in-cluster checks (Helm releases and API resources) on an interval and serves the deprecated and removed resources that were found, the scan duration and the number of failed scans as Prometheus metrics on /metrics.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if scanInterval <= 0 {
+			return fmt.Errorf("--interval must be greater than zero - got %s", scanInterval)
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		exporter := metrics.NewExporter(func() ([]*api.Output, error) {
+			return scanInCluster(cmd.Context())
+		})
+		go exporter.Run(scanInterval, nil)
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", exporter.Handler())
+		klog.Infof("serving metrics on %s/metrics", listenAddress)
+		err := http.ListenAndServe(listenAddress, mux)
+		if err != nil {
+			fmt.Println("Error serving metrics:", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var webhookCmd = &cobra.Command{
+	Use:   "webhook",
+	Short: "Serves a validating admission webhook that blocks removed apiVersions.",
+	Long:  `Serves an admission.k8s.io/v1 validating admission webhook on /validate. Objects with an apiVersion that is removed in the target version are denied, and objects with an apiVersion that is deprecated are allowed with a warning.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if (tlsCertFile == "") != (tlsKeyFile == "") {
+			return fmt.Errorf("--tls-cert-file and --tls-private-key-file must be used together")
+		}
+		if warnOnly && denyDeprecated {
+			return fmt.Errorf("--warn-only cannot be used with --deny-deprecated")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		w := webhook.NewWebhook(apiInstance)
+		w.WarnOnly = warnOnly
+		w.DenyDeprecated = denyDeprecated
+
+		mux := http.NewServeMux()
+		mux.Handle("/validate", w)
+		var err error
+		if tlsCertFile != "" {
+			klog.Infof("serving webhook on https://%s/validate", webhookAddress)
+			err = http.ListenAndServeTLS(webhookAddress, tlsCertFile, tlsKeyFile, mux)
+		} else {
+			klog.Infof("serving webhook on http://%s/validate", webhookAddress)
+			err = http.ListenAndServe(webhookAddress, mux)
+		}
+		if err != nil {
+			fmt.Println("Error serving webhook:", err)
+			os.Exit(1)
+		}
+	},
+}
+
 var detectCmd = &cobra.Command{
 	Use:   "detect [file to check or -]",
 	Short: "Checks a single file or stdin for deprecated apiVersions.",
@@ -410,7 +737,7 @@ var detectCmd = &cobra.Command{
 				os.Exit(1)
 			}
 
-			apiInstance.Outputs, err = apiInstance.IsVersioned(fileData)
+			err = pluto.Manifest{Data: fileData}.Scan(cmd.Context(), apiInstance)
 			if err != nil {
 				fmt.Println("Error checking for versions:", err)
 				os.Exit(1)
@@ -427,10 +754,7 @@ var detectCmd = &cobra.Command{
 		}
 
 		// File input
-		dir := finder.Dir{
-			Instance: apiInstance,
-		}
-		apiInstance.Outputs, err = dir.CheckForAPIVersion(args[0])
+		err = pluto.File{Path: args[0]}.Scan(cmd.Context(), apiInstance)
 		if err != nil {
 			fmt.Println("Error reading file:", err)
 			os.Exit(1)
@@ -446,12 +770,85 @@ var detectCmd = &cobra.Command{
 	},
 }
 
+var validateVersionsCmd = &cobra.Command{
+	Use:   "validate-versions [versions file]",
+	Short: "Checks a versions file for mistakes.",
+	Long:  `Checks a versions file for use with --additional-versions. Unknown keys, values that are not valid semver, versions that are removed before they are deprecated, replacements that are available after the version is removed, duplicates and replacements that are deprecated in the same version are reported.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			fmt.Println("Error reading versions file:", err)
+			os.Exit(1)
+		}
+		defaultVersions, _, err := api.GetDefaultVersionList(versionFileData)
+		if err != nil {
+			fmt.Println("Error reading default versions:", err)
+			os.Exit(1)
+		}
+		err = api.ValidateVersions(data, defaultVersions)
+		if err != nil {
+			fmt.Printf("%s is not valid:\n%s\n", args[0], err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s is valid\n", args[0])
+	},
+}
+
+var generateVersionsCmd = &cobra.Command{
+	Use:   "generate-versions",
+	Short: "Generates an additional versions file from CustomResourceDefinitions.",
+	Long:  `Reads CustomResourceDefinitions from a directory or the cluster and outputs a versions file for use with --additional-versions. Versions that are deprecated are deprecated in --version, and versions that are no longer served are removed in --version. The replacement is taken from the deprecationWarning, or is the storage version. A version that is deprecated or not served is never used as the replacement.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if !semver.IsValid(generateVersion) {
+			return fmt.Errorf("you must use valid semver for --version with a leading 'v' - got %s", generateVersion)
+		}
+		if fromCluster && cmd.Flags().Changed("directory") {
+			return fmt.Errorf("--from-cluster cannot be used with --directory")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		var crds []api.CustomResourceDefinition
+		var err error
+		if fromCluster {
+			var disCl *discoveryapi.DiscoveryClient
+			ctx, cancel := clusterContext(cmd.Context())
+			defer cancel()
+			disCl, err = discoveryapi.NewDiscoveryClient(ctx, "", kubeContext, apiInstance, kubeConfigPath)
+			if err != nil {
+				fmt.Println("Error creating Discovery REST Client:", err)
+				os.Exit(1)
+			}
+			crds, err = disCl.GetCRDs(ctx)
+		} else {
+			var dir *finder.Dir
+			dir, err = finder.NewFinder(directory, apiInstance)
+			if err == nil {
+				crds, err = dir.FindCRDs()
+			}
+		}
+		if err != nil {
+			fmt.Println("Error reading CustomResourceDefinitions:", err)
+			os.Exit(1)
+		}
+		versionFile := api.GenerateVersions(crds, generateComponent, generateVersion)
+		encoder := yaml.NewEncoder(os.Stdout)
+		encoder.SetIndent(2)
+		err = encoder.Encode(versionFile)
+		if err != nil {
+			fmt.Println("Error writing versions:", err)
+			os.Exit(1)
+		}
+	},
+}
+
 var listVersionsCmd = &cobra.Command{
 	Use:   "list-versions",
 	Short: "Outputs a JSON object of the versions that Pluto knows about.",
 	Long:  `Outputs a JSON object of the versions that Pluto knows about.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		err := apiInstance.PrintVersionList(outputFormat)
+		err := apiInstance.PrintVersionList(os.Stdout, outputFormat)
 		if err != nil {
 			os.Exit(1)
 		}
@@ -469,26 +866,122 @@ func Execute(VERSION string, COMMIT string, versionsFile []byte) {
 	}
 }
 
-func detectHelm() error {
-	h, err := helm.NewHelm(namespace, kubeContext, apiInstance, kubeConfigPath)
-	if err != nil {
-		return fmt.Errorf("error getting helm configuration: %v", err)
+// clusterContext returns the context for the calls to the cluster of a command. It is done
+// after --timeout, or on the first interrupt so that the outputs found so far can still be
+// displayed. A second interrupt stops pluto as usual.
+func clusterContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	context.AfterFunc(ctx, stop)
+	if timeout <= 0 {
+		return ctx, stop
 	}
-	err = h.FindVersions()
-	if err != nil {
-		return fmt.Errorf("Error running helm-detect: %v", err)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, func() {
+		cancel()
+		stop()
 	}
-	return nil
 }
 
-func detectAPIResources() error {
-	disCl, err := discoveryapi.NewDiscoveryClient(namespace, kubeContext, apiInstance, kubeConfigPath)
+// getTargetVersionFromCluster returns the version of the cluster, plus the minor versions in --upgrade-to
+func getTargetVersionFromCluster(ctx context.Context) (string, error) {
+	ctx, cancel := clusterContext(ctx)
+	defer cancel()
+	k, err := kube.GetConfigInstance(ctx, kubeContext, kubeConfigPath)
 	if err != nil {
-		return fmt.Errorf("Error creating Discovery REST Client: %v", err)
+		return "", fmt.Errorf("error getting kube configuration: %w", err)
 	}
-	err = disCl.GetApiResources()
+	version, err := k.ServerVersion()
 	if err != nil {
-		return fmt.Errorf("Error getting API resources using discovery client: %v", err)
+		return "", err
 	}
-	return nil
+	if upgradeTo != "" {
+		version, err = kube.UpgradeVersion(version, upgradeTo)
+		if err != nil {
+			return "", err
+		}
+	}
+	klog.V(2).Infof("using k8s target version %s from cluster", version)
+	return version, nil
+}
+
+// scanInCluster runs all in-cluster detections and returns the deprecated and removed resources.
+// A scan that takes longer than --timeout fails.
+func scanInCluster(ctx context.Context) ([]*api.Output, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	apiInstance.Outputs = nil
+	apiInstance.Baselined = nil
+	if err := inClusterSource(clusterFor(kubeContext)).Scan(ctx, apiInstance); err != nil {
+		return nil, err
+	}
+	apiInstance.FilterOutput()
+	return apiInstance.Outputs, nil
+}
+
+// clusterFor returns the cluster for a kube context, with the --kubeconfig and --namespace flags
+func clusterFor(kubeContext string) pluto.Cluster {
+	return pluto.Cluster{
+		KubeContext:    kubeContext,
+		KubeConfigPath: kubeConfigPath,
+		Namespace:      namespace,
+	}
+}
+
+// inClusterSource returns the source for all in-cluster detections: the Helm releases and
+// the API resources of the cluster
+func inClusterSource(cluster pluto.Cluster) pluto.Source {
+	return pluto.SourceFunc(func(ctx context.Context, instance *api.Instance) error {
+		err := pluto.HelmReleases{Cluster: cluster}.Scan(ctx, instance)
+		if err != nil {
+			return err
+		}
+		klog.V(5).Infof("after running detect-helm, exit-code is %d, and there are %d output items", instance.GetReturnCode(), len(instance.Outputs))
+		err = pluto.APIResources{Cluster: cluster}.Scan(ctx, instance)
+		if err != nil {
+			return err
+		}
+		klog.V(5).Infof("after running detect-api-resources, exit-code is %d, and there are %d output items", instance.GetReturnCode(), len(instance.Outputs))
+		return nil
+	})
+}
+
+// detectInContexts scans the source that newSource returns for --kube-context, or concurrently
+// for each context in --contexts or --all-contexts, and adds the outputs to apiInstance. A
+// context that fails is reported and the others are still scanned. If ctx is done, the outputs found so far are
+// kept and a warning is printed. The result gives the return code.
+func detectInContexts(ctx context.Context, newSource func(cluster pluto.Cluster) pluto.Source) (pluto.ContextsResult, error) {
+	contexts := kubeContexts
+	if allContexts {
+		var err error
+		contexts, err = kube.Contexts(kubeConfigPath)
+		if err != nil {
+			return pluto.ContextsResult{}, fmt.Errorf("error reading kube contexts: %w", err)
+		}
+	}
+	result, err := pluto.ScanContexts(ctx, apiInstance, contexts, func(c string) pluto.Source {
+		if c == "" {
+			c = kubeContext
+		}
+		return newSource(clusterFor(c))
+	})
+	for _, failed := range result.Failed {
+		fmt.Fprintf(os.Stderr, "Error scanning kube context %s: %v\n", failed.Context, failed.Err)
+	}
+	if result.Interrupted {
+		warnInterrupted(ctx)
+	}
+	return result, err
+}
+
+// warnInterrupted tells the user that the scan was stopped by ctx and that the output is partial
+func warnInterrupted(ctx context.Context) {
+	interrupted = true
+	reason := "was interrupted"
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		reason = fmt.Sprintf("timed out after %s", timeout)
+	}
+	fmt.Fprintf(os.Stderr, "Warning: the scan %s - the output only includes what was found before then\n", reason)
 }
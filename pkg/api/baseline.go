@@ -0,0 +1,153 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	fpath "path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+const baselinedHeader = "Baselined findings (not counted in the exit code):"
+
+// Baseline is a list of known findings. Outputs that are in the baseline
+// are reported separately and do not affect the return code.
+type Baseline struct {
+	Findings []BaselineFinding `json:"findings" yaml:"findings"`
+
+	keys map[BaselineFinding]bool
+}
+
+// BaselineFinding identifies a single finding in a baseline
+type BaselineFinding struct {
+	// FilePath is relative to the working directory, so baselines can be
+	// shared between machines
+	FilePath   string `json:"filePath,omitempty" yaml:"filePath,omitempty"`
+	Namespace  string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Kind       string `json:"kind" yaml:"kind"`
+	Name       string `json:"name" yaml:"name"`
+	APIVersion string `json:"apiVersion" yaml:"apiVersion"`
+	// Cluster is the kube context, for findings from a multi-context scan
+	Cluster string `json:"cluster,omitempty" yaml:"cluster,omitempty"`
+}
+
+// NewBaseline returns a baseline containing the outputs
+func NewBaseline(outputs []*Output) *Baseline {
+	baseline := &Baseline{}
+	for _, o := range outputs {
+		finding := newBaselineFinding(o)
+		if baseline.contains(finding) {
+			continue
+		}
+		baseline.add(finding)
+	}
+	sort.Slice(baseline.Findings, func(i, j int) bool {
+		a, b := baseline.Findings[i], baseline.Findings[j]
+		if a.Cluster != b.Cluster {
+			return a.Cluster < b.Cluster
+		}
+		if a.FilePath != b.FilePath {
+			return a.FilePath < b.FilePath
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.APIVersion < b.APIVersion
+	})
+	return baseline
+}
+
+// ReadBaseline reads a baseline file
+func ReadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading baseline %s: %w", path, err)
+	}
+	file := &Baseline{}
+	if err := yaml.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("error parsing baseline %s: %w", path, err)
+	}
+	baseline := &Baseline{}
+	for _, finding := range file.Findings {
+		baseline.add(finding)
+	}
+	return baseline, nil
+}
+
+// Write writes the baseline to a file
+func (baseline *Baseline) Write(path string) error {
+	var data bytes.Buffer
+	encoder := yaml.NewEncoder(&data)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(baseline); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data.Bytes(), 0644)
+}
+
+// Contains returns true if the output is in the baseline
+func (baseline *Baseline) Contains(o *Output) bool {
+	return baseline.contains(newBaselineFinding(o))
+}
+
+func (baseline *Baseline) contains(finding BaselineFinding) bool {
+	return baseline.keys[finding]
+}
+
+func (baseline *Baseline) add(finding BaselineFinding) {
+	if baseline.keys == nil {
+		baseline.keys = make(map[BaselineFinding]bool)
+	}
+	baseline.keys[finding] = true
+	baseline.Findings = append(baseline.Findings, finding)
+}
+
+func newBaselineFinding(o *Output) BaselineFinding {
+	finding := BaselineFinding{
+		FilePath:  relativePath(o.FilePath),
+		Cluster:   o.Cluster,
+		Namespace: o.Namespace,
+		Name:      o.Name,
+	}
+	if o.APIVersion != nil {
+		finding.Kind = o.APIVersion.Kind
+		finding.APIVersion = o.APIVersion.Name
+	}
+	return finding
+}
+
+// relativePath returns an absolute path relative to the working directory when possible,
+// using / as the separator
+func relativePath(path string) string {
+	if path == "" {
+		return path
+	}
+	if cwd, err := os.Getwd(); err == nil && fpath.IsAbs(path) {
+		if rel, err := fpath.Rel(cwd, path); err == nil {
+			path = rel
+		}
+	}
+	return fpath.ToSlash(path)
+}
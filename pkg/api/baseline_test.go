@@ -0,0 +1,107 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"os"
+	fpath "path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBaseline(t *testing.T) {
+	cwd, err := os.Getwd()
+	assert.NoError(t, err)
+	inCwd := &Output{
+		Name:       "in cwd",
+		FilePath:   cwd + string(os.PathSeparator) + "manifests" + string(os.PathSeparator) + "deployment.yaml",
+		APIVersion: testOutput1.APIVersion,
+	}
+
+	outsideCwd := &Output{
+		Name:       "outside cwd",
+		FilePath:   fpath.Join(fpath.Dir(cwd), "manifests", "deployment.yaml"),
+		APIVersion: testOutput1.APIVersion,
+	}
+
+	baseline := NewBaseline([]*Output{testOutput2, testOutput1, inCwd, testOutput1, outsideCwd})
+	assert.Equal(t, []BaselineFinding{
+		{Name: "some name two", Kind: "Deployment", APIVersion: "extensions/v1beta1"},
+		{FilePath: "../manifests/deployment.yaml", Name: "outside cwd", Kind: "Deployment", APIVersion: "extensions/v1beta1"},
+		{FilePath: "manifests/deployment.yaml", Name: "in cwd", Kind: "Deployment", APIVersion: "extensions/v1beta1"},
+		{FilePath: "path-to-file", Namespace: "pluto-namespace", Name: "some name one", Kind: "Deployment", APIVersion: "extensions/v1beta1"},
+	}, baseline.Findings)
+	assert.True(t, baseline.Contains(testOutput1))
+	assert.True(t, baseline.Contains(inCwd))
+	assert.False(t, baseline.Contains(testOutputNoOutput))
+
+	inCluster := *testOutput2
+	inCluster.Cluster = "production"
+	assert.False(t, baseline.Contains(&inCluster))
+	baseline = NewBaseline([]*Output{&inCluster, testOutput2})
+	assert.Equal(t, []BaselineFinding{
+		{Name: "some name two", Kind: "Deployment", APIVersion: "extensions/v1beta1"},
+		{Name: "some name two", Kind: "Deployment", APIVersion: "extensions/v1beta1", Cluster: "production"},
+	}, baseline.Findings)
+}
+
+func TestReadBaseline(t *testing.T) {
+	path := t.TempDir() + string(os.PathSeparator) + "baseline.yaml"
+	err := NewBaseline([]*Output{testOutput1}).Write(path)
+	assert.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, `findings:
+  - filePath: path-to-file
+    namespace: pluto-namespace
+    kind: Deployment
+    name: some name one
+    apiVersion: extensions/v1beta1
+`, string(data))
+
+	baseline, err := ReadBaseline(path)
+	assert.NoError(t, err)
+	assert.True(t, baseline.Contains(testOutput1))
+	assert.False(t, baseline.Contains(testOutput2))
+
+	_, err = ReadBaseline(t.TempDir() + string(os.PathSeparator) + "missing.yaml")
+	assert.Error(t, err)
+}
+
+func TestInstance_FilterOutput_baseline(t *testing.T) {
+	instance := &Instance{
+		TargetVersions: map[string]string{
+			"foo": "v1.16.0",
+		},
+		Outputs: []*Output{
+			testOutput1,
+			testOutput2,
+			testOutputNoOutput,
+		},
+		Components: []string{"foo"},
+		Baseline:   NewBaseline([]*Output{testOutput2}),
+	}
+	instance.FilterOutput()
+	assert.Equal(t, []*Output{testOutput1}, instance.Outputs)
+	assert.Equal(t, []*Output{testOutput2}, instance.Baselined)
+	assert.Equal(t, 3, instance.GetReturnCode())
+
+	// filtering again keeps the baselined outputs
+	instance.FilterOutput()
+	assert.Equal(t, []*Output{testOutput1}, instance.Outputs)
+	assert.Equal(t, []*Output{testOutput2}, instance.Baselined)
+}
@@ -14,7 +14,10 @@
 
 package api
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+)
 
 // Column is an interface for printing columns
 type column interface {
@@ -39,6 +42,17 @@ var PossibleColumnNames = []string{
 	"COMPONENT",
 	"REPL AVAIL",
 	"REPL AVAIL IN",
+	"LINE",
+	"COLUMN",
+	"SUPPRESSED",
+	"INTRODUCED IN",
+	"TOO NEW",
+	"USER",
+	"USER AGENT",
+	"COUNT",
+	"LAST SEEN",
+	"MANAGER",
+	"CLUSTER",
 }
 
 var possibleColumns = []column{
@@ -55,6 +69,17 @@ var possibleColumns = []column{
 	new(filepath),
 	new(replacementAvailable),
 	new(replacementAvailableIn),
+	new(line),
+	new(col),
+	new(suppressed),
+	new(introducedIn),
+	new(tooNew),
+	new(user),
+	new(userAgent),
+	new(count),
+	new(lastSeen),
+	new(manager),
+	new(cluster),
 }
 
 // name is the output name
@@ -74,6 +99,28 @@ func (f filepath) value(output *Output) string {
 	return output.FilePath
 }
 
+// line is the line of the apiVersion in the file
+type line struct{}
+
+func (l line) header() string { return "LINE" }
+func (l line) value(output *Output) string {
+	if output.Line == 0 {
+		return "<UNKNOWN>"
+	}
+	return strconv.Itoa(output.Line)
+}
+
+// col is the column of the apiVersion in the file
+type col struct{}
+
+func (c col) header() string { return "COLUMN" }
+func (c col) value(output *Output) string {
+	if output.Column == 0 {
+		return "<UNKNOWN>"
+	}
+	return strconv.Itoa(output.Column)
+}
+
 // namespace is the output namespace if available
 type namespace struct{}
 
@@ -149,6 +196,94 @@ func (rai replacementAvailableIn) value(output *Output) string {
 	return output.APIVersion.ReplacementAvailableIn
 }
 
+// suppressed is the output for the boolean Suppressed
+type suppressed struct{}
+
+func (s suppressed) header() string { return "SUPPRESSED" }
+func (s suppressed) value(output *Output) string {
+	return fmt.Sprintf("%t", output.Suppressed)
+}
+
+// introducedIn is the string value of when an output was introduced
+type introducedIn struct{}
+
+func (ii introducedIn) header() string              { return "INTRODUCED IN" }
+func (ii introducedIn) value(output *Output) string { return output.APIVersion.IntroducedIn }
+
+// tooNew is the output for the boolean TooNew
+type tooNew struct{}
+
+func (tn tooNew) header() string { return "TOO NEW" }
+func (tn tooNew) value(output *Output) string {
+	return fmt.Sprintf("%t", output.TooNew)
+}
+
+// user is the user that called the API
+type user struct{}
+
+func (u user) header() string { return "USER" }
+func (u user) value(output *Output) string {
+	if output.User == "" {
+		return "<UNKNOWN>"
+	}
+	return output.User
+}
+
+// userAgent is the user agent that called the API
+type userAgent struct{}
+
+func (ua userAgent) header() string { return "USER AGENT" }
+func (ua userAgent) value(output *Output) string {
+	if output.UserAgent == "" {
+		return "<UNKNOWN>"
+	}
+	return output.UserAgent
+}
+
+// count is the number of calls to the API
+type count struct{}
+
+func (c count) header() string              { return "COUNT" }
+func (c count) value(output *Output) string { return strconv.Itoa(output.Count) }
+
+// lastSeen is the time of the last call to the API
+type lastSeen struct{}
+
+func (ls lastSeen) header() string { return "LAST SEEN" }
+func (ls lastSeen) value(output *Output) string {
+	if output.LastSeen == "" {
+		return "<UNKNOWN>"
+	}
+	return output.LastSeen
+}
+
+// manager is the field manager that last wrote the object with the apiVersion
+type manager struct{}
+
+func (m manager) header() string { return "MANAGER" }
+func (m manager) value(output *Output) string {
+	if output.Manager == "" {
+		return "<UNKNOWN>"
+	}
+	return output.Manager
+}
+
+// cluster is the kube context that the object was found in
+type cluster struct{}
+
+func (c cluster) header() string              { return "CLUSTER" }
+func (c cluster) value(output *Output) string { return output.Cluster }
+
+// baselined is whether the output is in the baseline
+type baselined struct {
+	baseline *Baseline
+}
+
+func (b baselined) header() string { return "BASELINED" }
+func (b baselined) value(output *Output) string {
+	return fmt.Sprintf("%t", b.baseline.Contains(output))
+}
+
 // normalColumns returns the list of columns for -onormal
 func (instance *Instance) normalColumns() columnList {
 	columnList := columnList{
@@ -160,7 +295,8 @@ func (instance *Instance) normalColumns() columnList {
 		5: new(deprecated),
 		6: new(replacementAvailable),
 	}
-	return columnList
+	columnList = instance.withAuditColumns(columnList, new(namespace), new(userAgent), new(count))
+	return instance.withClusterColumn(columnList)
 }
 
 // wideColumns returns the list of columns for -owide
@@ -178,7 +314,66 @@ func (instance *Instance) wideColumns() columnList {
 		9:  new(replacementAvailable),
 		10: new(replacementAvailableIn),
 	}
-	return columnList
+	columnList = instance.withAuditColumns(columnList, new(userAgent), new(count), new(lastSeen))
+	return instance.withClusterColumn(columnList)
+}
+
+// withAuditColumns adds the audit columns after the other columns
+// if any of the outputs were found in an audit log
+func (instance *Instance) withAuditColumns(columns columnList, auditColumns ...column) columnList {
+	found := false
+	for _, o := range append(append([]*Output{}, instance.Outputs...), instance.Baselined...) {
+		if o.Count > 0 {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return columns
+	}
+	withAudit := columnList{}
+	for i, c := range columns {
+		withAudit[i] = c
+	}
+	for i, c := range auditColumns {
+		withAudit[len(columns)+i] = c
+	}
+	return withAudit
+}
+
+// withClusterColumn adds the CLUSTER column before the other columns
+// if any of the outputs were found in a cluster of a multi-context scan
+func (instance *Instance) withClusterColumn(columns columnList) columnList {
+	found := false
+	for _, o := range append(append([]*Output{}, instance.Outputs...), instance.Baselined...) {
+		if o.Cluster != "" {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return columns
+	}
+	withCluster := columnList{0: new(cluster)}
+	for i, c := range columns {
+		withCluster[i+1] = c
+	}
+	return withCluster
+}
+
+// withBaselinedColumn adds the BASELINED column after the other columns if a baseline is used
+func (instance *Instance) withBaselinedColumn(columns columnList) columnList {
+	if instance.Baseline == nil {
+		return columns
+	}
+	withBaselined := columnList{}
+	last := -1
+	for i, c := range columns {
+		withBaselined[i] = c
+		last = max(last, i)
+	}
+	withBaselined[last+1] = baselined{baseline: instance.Baseline}
+	return withBaselined
 }
 
 // customColumns returns a custom list of columns based on names
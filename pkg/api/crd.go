@@ -0,0 +1,243 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	kubeversion "k8s.io/apimachinery/pkg/version"
+	"k8s.io/klog/v2"
+)
+
+// CustomResourceDefinition is a stub of a CustomResourceDefinition that has just the
+// fields needed to find its deprecated versions
+type CustomResourceDefinition struct {
+	Kind       string                       `json:"kind" yaml:"kind"`
+	APIVersion string                       `json:"apiVersion" yaml:"apiVersion"`
+	Metadata   StubMeta                     `json:"metadata" yaml:"metadata"`
+	Spec       CustomResourceDefinitionSpec `json:"spec" yaml:"spec"`
+	Items      []CustomResourceDefinition   `json:"items" yaml:"items"`
+}
+
+// CustomResourceDefinitionSpec is the spec of a CustomResourceDefinition
+type CustomResourceDefinitionSpec struct {
+	Group    string                            `json:"group" yaml:"group"`
+	Names    CustomResourceDefinitionNames     `json:"names" yaml:"names"`
+	Versions []CustomResourceDefinitionVersion `json:"versions" yaml:"versions"`
+}
+
+// CustomResourceDefinitionNames holds the kind of a CustomResourceDefinition
+type CustomResourceDefinitionNames struct {
+	Kind string `json:"kind" yaml:"kind"`
+}
+
+// CustomResourceDefinitionVersion is a version of a CustomResourceDefinition
+type CustomResourceDefinitionVersion struct {
+	Name               string `json:"name" yaml:"name"`
+	Served             bool   `json:"served" yaml:"served"`
+	Storage            bool   `json:"storage" yaml:"storage"`
+	Deprecated         bool   `json:"deprecated" yaml:"deprecated"`
+	DeprecationWarning string `json:"deprecationWarning" yaml:"deprecationWarning"`
+}
+
+// warningReplacement matches the replacement in a deprecationWarning, like
+// "example.com/v1alpha1 Widget is deprecated; use example.com/v1 Widget"
+var warningReplacement = regexp.MustCompile(`\buse (\S+/\S+)`)
+
+// ReadCRDs returns the CustomResourceDefinitions in json or yaml data. Lists are
+// expanded, and any other kinds of manifest are skipped.
+func ReadCRDs(data []byte) ([]CustomResourceDefinition, error) {
+	var crds []CustomResourceDefinition
+	crd := CustomResourceDefinition{}
+	if err := json.Unmarshal(data, &crd); err == nil {
+		expandCRDList(&crds, crd)
+		return crds, nil
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var node yaml.Node
+		err := decoder.Decode(&node)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		crd := CustomResourceDefinition{}
+		if err := node.Decode(&crd); err != nil {
+			// manifests of other kinds may not fit the stub
+			klog.V(8).Infof("skipping manifest that is not a CustomResourceDefinition: %s", err)
+			continue
+		}
+		expandCRDList(&crds, crd)
+	}
+	return crds, nil
+}
+
+// expandCRDList adds the CustomResourceDefinitions in a manifest, which may be a List
+func expandCRDList(crds *[]CustomResourceDefinition, crd CustomResourceDefinition) {
+	if len(crd.Items) > 0 {
+		for _, item := range crd.Items {
+			expandCRDList(crds, item)
+		}
+		return
+	}
+	if crd.Kind != "CustomResourceDefinition" || !strings.HasPrefix(crd.APIVersion, "apiextensions.k8s.io/") {
+		return
+	}
+	*crds = append(*crds, crd)
+}
+
+// DeprecatedVersions returns a version for each deprecated version that the
+// CustomResourceDefinition serves. A CustomResourceDefinition does not say when a version
+// was deprecated, so the versions are deprecated in deprecatedIn. The replacement is
+// chosen by replacementVersion.
+func (crd CustomResourceDefinition) DeprecatedVersions(deprecatedIn string) []Version {
+	replacement := crd.replacementVersion()
+
+	var versions []Version
+	for _, v := range crd.Spec.Versions {
+		if !v.Served || !v.Deprecated {
+			continue
+		}
+		if v.DeprecationWarning != "" {
+			klog.V(3).Infof("%s %s/%s is deprecated: %s", crd.Spec.Names.Kind, crd.Spec.Group, v.Name, v.DeprecationWarning)
+		}
+		version := Version{
+			Name:         crd.Spec.Group + "/" + v.Name,
+			Kind:         crd.Spec.Names.Kind,
+			DeprecatedIn: deprecatedIn,
+			Component:    "k8s",
+		}
+		if replacement != "" {
+			version.ReplacementAPI = replacement
+			version.ReplacementAvailableIn = deprecatedIn
+		}
+		versions = append(versions, version)
+	}
+	return versions
+}
+
+// replacementVersion returns the version that replaces the deprecated and unserved
+// versions of the CustomResourceDefinition. It must be served and not deprecated.
+// The storage version is preferred, otherwise the newest such version is used.
+// If there is none, it returns an empty string.
+func (crd CustomResourceDefinition) replacementVersion() string {
+	var newest string
+	for _, v := range crd.Spec.Versions {
+		if !v.Served || v.Deprecated {
+			continue
+		}
+		if v.Storage {
+			return crd.Spec.Group + "/" + v.Name
+		}
+		if newest == "" || kubeversion.CompareKubeAwareVersionStrings(v.Name, newest) > 0 {
+			newest = v.Name
+		}
+	}
+	if newest == "" {
+		return ""
+	}
+	return crd.Spec.Group + "/" + newest
+}
+
+// isReplacement checks if name can replace the versions of the CustomResourceDefinition.
+// Versions of the CustomResourceDefinition itself must be served and not deprecated.
+func (crd CustomResourceDefinition) isReplacement(name string) bool {
+	for _, v := range crd.Spec.Versions {
+		if crd.Spec.Group+"/"+v.Name == name {
+			return v.Served && !v.Deprecated
+		}
+	}
+	return true
+}
+
+// CRDVersions returns the deprecated versions that are served by the
+// CustomResourceDefinitions in data, deprecated in deprecatedIn
+func CRDVersions(data []byte, deprecatedIn string) ([]Version, error) {
+	crds, err := ReadCRDs(data)
+	if err != nil {
+		return nil, err
+	}
+	var versions []Version
+	for _, crd := range crds {
+		versions = append(versions, crd.DeprecatedVersions(deprecatedIn)...)
+	}
+	return versions, nil
+}
+
+// AddCRDVersions adds the deprecated versions that are served by the
+// CustomResourceDefinitions in data. They are deprecated in the k8s target version.
+func (instance *Instance) AddCRDVersions(data []byte) error {
+	if !bytes.Contains(data, []byte("CustomResourceDefinition")) {
+		return nil
+	}
+	versions, err := CRDVersions(data, instance.TargetVersions["k8s"])
+	if err != nil {
+		return err
+	}
+	instance.AddDeprecatedVersions(versions)
+	return nil
+}
+
+// GenerateVersions returns a versions file with a version for each version of the
+// CustomResourceDefinitions that is deprecated or no longer served. Deprecated versions
+// are deprecated in version, and versions that are not served are removed in version.
+// The replacement is taken from the deprecationWarning, or is chosen by replacementVersion.
+// Versions that are not for the k8s component get a target version of version.
+func GenerateVersions(crds []CustomResourceDefinition, component string, version string) VersionFile {
+	versionFile := VersionFile{
+		DeprecatedVersions: []Version{},
+	}
+	if component != "k8s" {
+		versionFile.TargetVersions = map[string]string{component: version}
+	}
+	for _, crd := range crds {
+		replacement := crd.replacementVersion()
+		for _, v := range crd.Spec.Versions {
+			if !v.Deprecated && v.Served {
+				continue
+			}
+			generated := Version{
+				Name:         crd.Spec.Group + "/" + v.Name,
+				Kind:         crd.Spec.Names.Kind,
+				DeprecatedIn: version,
+				Component:    component,
+			}
+			if !v.Served {
+				generated.RemovedIn = version
+			}
+			if match := warningReplacement.FindStringSubmatch(v.DeprecationWarning); match != nil && crd.isReplacement(match[1]) {
+				generated.ReplacementAPI = match[1]
+			} else {
+				generated.ReplacementAPI = replacement
+			}
+			if generated.ReplacementAPI != "" {
+				generated.ReplacementAvailableIn = version
+			}
+			if generated.isContainedIn(versionFile.DeprecatedVersions) {
+				continue
+			}
+			versionFile.DeprecatedVersions = append(versionFile.DeprecatedVersions, generated)
+		}
+	}
+	return versionFile
+}
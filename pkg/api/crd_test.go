@@ -0,0 +1,219 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+var testCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+    plural: widgets
+  versions:
+  - name: v1alpha1
+    served: false
+    storage: false
+    deprecated: true
+  - name: v1beta1
+    served: true
+    storage: false
+    deprecated: true
+    deprecationWarning: example.com/v1beta1 Widget is deprecated; use example.com/v1 Widget
+  - name: v1
+    served: true
+    storage: true
+`
+
+// testCRDDeprecatedStorage stores a deprecated version, and has two versions that are
+// served and not deprecated, so the newest of them is the replacement
+var testCRDDeprecatedStorage = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+    plural: widgets
+  versions:
+  - name: v1alpha1
+    served: false
+    storage: false
+  - name: v1beta1
+    served: true
+    storage: true
+    deprecated: true
+    deprecationWarning: example.com/v1beta1 Widget is deprecated; use example.com/v1beta1 Widget
+  - name: v1
+    served: true
+    storage: false
+  - name: v1beta2
+    served: true
+    storage: false
+`
+
+func TestCRDVersions(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []Version
+		wantErr bool
+	}{
+		{
+			name: "deprecated served version",
+			data: testCRD,
+			want: []Version{
+				{Name: "example.com/v1beta1", Kind: "Widget", DeprecatedIn: "v1.22.0", ReplacementAPI: "example.com/v1", ReplacementAvailableIn: "v1.22.0", Component: "k8s"},
+			},
+		},
+		{
+			name: "other manifests",
+			data: "apiVersion: v1\nkind: ConfigMap\nspec: not a map\n---\n" + testCRD,
+			want: []Version{
+				{Name: "example.com/v1beta1", Kind: "Widget", DeprecatedIn: "v1.22.0", ReplacementAPI: "example.com/v1", ReplacementAvailableIn: "v1.22.0", Component: "k8s"},
+			},
+		},
+		{
+			name: "json list with deprecated storage version",
+			data: `{"apiVersion": "v1", "kind": "List", "items": [{"apiVersion": "apiextensions.k8s.io/v1", "kind": "CustomResourceDefinition", "spec": {"group": "example.com", "names": {"kind": "Gadget"}, "versions": [{"name": "v1", "served": true, "storage": true, "deprecated": true}]}}]}`,
+			want: []Version{
+				{Name: "example.com/v1", Kind: "Gadget", DeprecatedIn: "v1.22.0", Component: "k8s"},
+			},
+		},
+		{
+			name: "deprecated storage version",
+			data: testCRDDeprecatedStorage,
+			want: []Version{
+				{Name: "example.com/v1beta1", Kind: "Widget", DeprecatedIn: "v1.22.0", ReplacementAPI: "example.com/v1", ReplacementAvailableIn: "v1.22.0", Component: "k8s"},
+			},
+		},
+		{
+			name: "no crds",
+			data: "apiVersion: apps/v1\nkind: Deployment\n",
+		},
+		{
+			name:    "invalid yaml",
+			data:    "*.",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CRDVersions([]byte(tt.data), "v1.22.0")
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestInstance_AddCRDVersions(t *testing.T) {
+	instance := &Instance{
+		TargetVersions:     map[string]string{"k8s": "v1.22.0"},
+		DeprecatedVersions: []Version{testVersionDeployment},
+	}
+	err := instance.AddCRDVersions([]byte(testCRD + "---\napiVersion: example.com/v1beta1\nkind: Widget\nmetadata:\n  name: foo\n"))
+	assert.NoError(t, err)
+	outputs, err := instance.IsVersioned([]byte("apiVersion: example.com/v1beta1\nkind: Widget\nmetadata:\n  name: foo\n"))
+	assert.NoError(t, err)
+	assert.Len(t, outputs, 1)
+	assert.Equal(t, "example.com/v1", outputs[0].APIVersion.ReplacementAPI)
+}
+
+func TestGenerateVersions(t *testing.T) {
+	crds, err := ReadCRDs([]byte(testCRD))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		component string
+		want      VersionFile
+	}{
+		{
+			name:      "custom component",
+			component: "widgets",
+			want: VersionFile{
+				DeprecatedVersions: []Version{
+					{Name: "example.com/v1alpha1", Kind: "Widget", DeprecatedIn: "v2.0.0", RemovedIn: "v2.0.0", ReplacementAPI: "example.com/v1", ReplacementAvailableIn: "v2.0.0", Component: "widgets"},
+					{Name: "example.com/v1beta1", Kind: "Widget", DeprecatedIn: "v2.0.0", ReplacementAPI: "example.com/v1", ReplacementAvailableIn: "v2.0.0", Component: "widgets"},
+				},
+				TargetVersions: map[string]string{"widgets": "v2.0.0"},
+			},
+		},
+		{
+			name:      "k8s component",
+			component: "k8s",
+			want: VersionFile{
+				DeprecatedVersions: []Version{
+					{Name: "example.com/v1alpha1", Kind: "Widget", DeprecatedIn: "v2.0.0", RemovedIn: "v2.0.0", ReplacementAPI: "example.com/v1", ReplacementAvailableIn: "v2.0.0", Component: "k8s"},
+					{Name: "example.com/v1beta1", Kind: "Widget", DeprecatedIn: "v2.0.0", ReplacementAPI: "example.com/v1", ReplacementAvailableIn: "v2.0.0", Component: "k8s"},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := GenerateVersions(crds, tt.component, "v2.0.0")
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGenerateVersions_replacementFromWarning(t *testing.T) {
+	crds := []CustomResourceDefinition{{
+		Spec: CustomResourceDefinitionSpec{
+			Group: "example.com",
+			Names: CustomResourceDefinitionNames{Kind: "Widget"},
+			Versions: []CustomResourceDefinitionVersion{
+				{Name: "v1", Served: true, Storage: true, Deprecated: true, DeprecationWarning: "Widget is deprecated, use gadgets.example.com/v1 Gadget instead"},
+			},
+		},
+	}}
+	got := GenerateVersions(crds, "k8s", "v1.0.0")
+	assert.Equal(t, []Version{
+		{Name: "example.com/v1", Kind: "Widget", DeprecatedIn: "v1.0.0", ReplacementAPI: "gadgets.example.com/v1", ReplacementAvailableIn: "v1.0.0", Component: "k8s"},
+	}, got.DeprecatedVersions)
+}
+
+func TestGenerateVersions_deprecatedStorage(t *testing.T) {
+	crds, err := ReadCRDs([]byte(testCRDDeprecatedStorage))
+	assert.NoError(t, err)
+
+	got := GenerateVersions(crds, "widgets", "v2.0.0")
+	assert.Equal(t, []Version{
+		{Name: "example.com/v1alpha1", Kind: "Widget", DeprecatedIn: "v2.0.0", RemovedIn: "v2.0.0", ReplacementAPI: "example.com/v1", ReplacementAvailableIn: "v2.0.0", Component: "widgets"},
+		{Name: "example.com/v1beta1", Kind: "Widget", DeprecatedIn: "v2.0.0", ReplacementAPI: "example.com/v1", ReplacementAvailableIn: "v2.0.0", Component: "widgets"},
+	}, got.DeprecatedVersions)
+
+	for _, crd := range []string{testCRD, testCRDDeprecatedStorage} {
+		crds, err := ReadCRDs([]byte(crd))
+		assert.NoError(t, err)
+		data, err := yaml.Marshal(GenerateVersions(crds, "widgets", "v2.0.0"))
+		assert.NoError(t, err)
+		assert.NoError(t, ValidateVersions(data, nil))
+	}
+}
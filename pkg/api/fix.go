@@ -0,0 +1,193 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+	"k8s.io/klog/v2"
+)
+
+// apiVersionEdit is a replacement of an apiVersion value at a byte offset
+type apiVersionEdit struct {
+	offset int
+	old    string
+	new    string
+}
+
+// manifestFixer collects the apiVersion edits for a single file
+type manifestFixer struct {
+	instance   *Instance
+	data       []byte
+	lines      []string
+	lineStarts []int
+	edits      []apiVersionEdit
+	outputs    []*Output
+}
+
+// FixVersions rewrites the apiVersion of every resource in data that is deprecated or
+// removed in the target version, and whose replacement is available in the target version.
+// Suppressed resources are not changed.
+// Only the apiVersion values are changed, so comments, key order and document
+// separators are left as they are. Fields whose schema differs in the replacement
+// apiVersion are not migrated. It returns the new data and an output for every
+// resource that was changed. If nothing was changed the data is returned as-is.
+func (instance *Instance) FixVersions(data []byte) ([]byte, []*Output, error) {
+	f := &manifestFixer{
+		instance:   instance,
+		data:       data,
+		lines:      strings.Split(string(data), "\n"),
+		lineStarts: []int{0},
+	}
+	for i, b := range data {
+		if b == '\n' {
+			f.lineStarts = append(f.lineStarts, i+1)
+		}
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	document := 0
+	for {
+		var node yaml.Node
+		err := decoder.Decode(&node)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, nil, err
+		}
+		document++
+		if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+			f.fixNode(node.Content[0], document)
+		}
+	}
+	if len(f.edits) == 0 {
+		return data, nil, nil
+	}
+	return f.apply(), f.outputs, nil
+}
+
+// fixNode records an edit for a single manifest, or for each item if it is a List
+func (f *manifestFixer) fixNode(node *yaml.Node, document int) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	stub := &Stub{}
+	if err := node.Decode(stub); err != nil {
+		klog.V(2).Infof("skipping for invalid yaml in manifest: %s", err)
+		return
+	}
+	if items := mappingValue(node, "items"); items != nil && items.Kind == yaml.SequenceNode && len(stub.Items) > 0 {
+		for _, item := range items.Content {
+			f.fixNode(item, document)
+		}
+		return
+	}
+
+	stub.IgnoreComment = hasIgnoreComment(f.lines, node.Line)
+	if stub.isSuppressed() {
+		return
+	}
+	version := f.instance.checkVersion(stub)
+	if version == nil || !f.instance.isFixable(version) {
+		return
+	}
+	value := mappingValue(node, "apiVersion")
+	if value == nil || value.Kind != yaml.ScalarNode {
+		return
+	}
+	column := value.Column
+	if value.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 {
+		// skip the opening quote
+		column++
+	}
+	offset, ok := f.offset(value.Line, column)
+	if !ok || !bytes.HasPrefix(f.data[offset:], []byte(value.Value)) {
+		klog.V(2).Infof("apiVersion %s was not found at line %d column %d, skipping", value.Value, value.Line, value.Column)
+		return
+	}
+	f.edits = append(f.edits, apiVersionEdit{
+		offset: offset,
+		old:    value.Value,
+		new:    version.ReplacementAPI,
+	})
+	f.outputs = append(f.outputs, &Output{
+		Name:       stub.Metadata.Name,
+		Namespace:  stub.Metadata.Namespace,
+		APIVersion: version,
+		Document:   document,
+		Line:       value.Line,
+		Column:     value.Column,
+	})
+}
+
+// offset converts a line and column, both starting at 1, to a byte offset in the data
+func (f *manifestFixer) offset(line int, column int) (int, bool) {
+	if line < 1 || line > len(f.lineStarts) {
+		return 0, false
+	}
+	offset := f.lineStarts[line-1]
+	for c := 1; c < column; c++ {
+		if offset >= len(f.data) {
+			return 0, false
+		}
+		_, size := utf8.DecodeRune(f.data[offset:])
+		offset += size
+	}
+	return offset, true
+}
+
+// apply returns a copy of the data with all of the edits made
+func (f *manifestFixer) apply() []byte {
+	// apply from the end so earlier offsets stay valid
+	edits := append([]apiVersionEdit{}, f.edits...)
+	sort.Slice(edits, func(i, j int) bool { return edits[i].offset > edits[j].offset })
+	fixed := append([]byte{}, f.data...)
+	for _, edit := range edits {
+		end := edit.offset + len(edit.old)
+		fixed = append(fixed[:edit.offset], append([]byte(edit.new), fixed[end:]...)...)
+	}
+	return fixed
+}
+
+// isFixable returns true if the version is deprecated or removed in the target
+// version and there is a replacement that is available in the target version
+func (instance *Instance) isFixable(v *Version) bool {
+	if !StringInSlice(v.Component, instance.Components) {
+		return false
+	}
+	if v.ReplacementAPI == "" || v.ReplacementAPI == v.Name {
+		return false
+	}
+	if !v.isDeprecatedIn(instance.TargetVersions) && !v.isRemovedIn(instance.TargetVersions) {
+		return false
+	}
+	return v.isReplacementAvailableIn(instance.TargetVersions)
+}
+
+// mappingValue returns the value for a key in a yaml mapping node
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
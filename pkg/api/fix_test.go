@@ -0,0 +1,102 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstance_FixVersions(t *testing.T) {
+	tests := []struct {
+		name           string
+		targetVersions map[string]string
+		data           string
+		want           string
+		wantOutputs    []*Output
+		wantErr        bool
+	}{
+		{
+			name: "keeps comments and order",
+			data: "# leading comment\nkind: Deployment\napiVersion: extensions/v1beta1 # trailing comment\nmetadata:\n  name: foo\n",
+			want: "# leading comment\nkind: Deployment\napiVersion: apps/v1 # trailing comment\nmetadata:\n  name: foo\n",
+			wantOutputs: []*Output{
+				{Name: "foo", APIVersion: &testVersionDeployment, Document: 1, Line: 3, Column: 13},
+			},
+		},
+		{
+			name: "multiple documents and quoted values",
+			data: "apiVersion: apps/v1\nkind: Deployment\n---\napiVersion: \"extensions/v1beta1\"\nkind: Deployment\n",
+			want: "apiVersion: apps/v1\nkind: Deployment\n---\napiVersion: \"apps/v1\"\nkind: Deployment\n",
+			wantOutputs: []*Output{
+				{APIVersion: &testVersionDeployment, Document: 2, Line: 4, Column: 13},
+			},
+		},
+		{
+			name: "list items",
+			data: "kind: List\napiVersion: v1\nitems:\n- kind: Deployment\n  apiVersion: extensions/v1beta1\n",
+			want: "kind: List\napiVersion: v1\nitems:\n- kind: Deployment\n  apiVersion: apps/v1\n",
+			wantOutputs: []*Output{
+				{APIVersion: &testVersionDeployment, Document: 1, Line: 5, Column: 15},
+			},
+		},
+		{
+			name: "json",
+			data: `{"kind": "Deployment", "apiVersion": "extensions/v1beta1"}`,
+			want: `{"kind": "Deployment", "apiVersion": "apps/v1"}`,
+			wantOutputs: []*Output{
+				{APIVersion: &testVersionDeployment, Document: 1, Line: 1, Column: 38},
+			},
+		},
+		{
+			name: "suppressed",
+			data: "# pluto:ignore\nkind: Deployment\napiVersion: extensions/v1beta1\n---\nkind: Deployment\napiVersion: extensions/v1beta1\nmetadata:\n  annotations:\n    pluto.fairwinds.com/ignore: \"true\"\n",
+			want: "# pluto:ignore\nkind: Deployment\napiVersion: extensions/v1beta1\n---\nkind: Deployment\napiVersion: extensions/v1beta1\nmetadata:\n  annotations:\n    pluto.fairwinds.com/ignore: \"true\"\n",
+		},
+		{
+			name:           "replacement not available in target",
+			targetVersions: map[string]string{"k8s": "v1.9.0"},
+			data:           "kind: Deployment\napiVersion: extensions/v1beta1\n",
+			want:           "kind: Deployment\napiVersion: extensions/v1beta1\n",
+		},
+		{
+			name:    "not yaml",
+			data:    "*.",
+			want:    "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instance := &Instance{
+				TargetVersions:     map[string]string{"k8s": "v1.16.0"},
+				DeprecatedVersions: []Version{testVersionDeployment},
+				Components:         []string{"k8s"},
+			}
+			if tt.targetVersions != nil {
+				instance.TargetVersions = tt.targetVersions
+			}
+			got, outputs, err := instance.FixVersions([]byte(tt.data))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+			assert.Equal(t, tt.wantOutputs, outputs)
+		})
+	}
+}
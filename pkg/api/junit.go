@@ -0,0 +1,143 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// junitOut builds a JUnit XML report where every resource that was scanned is a test
+// case. Resources using removed or too new apiVersions fail, resources using deprecated
+// apiVersions are skipped, and all other resources pass. Test cases are grouped into suites by file
+// path, or by namespace if there is no file. Resources that are suppressed or in the baseline are skipped.
+func (instance *Instance) junitOut(outputs []*Output) ([]byte, error) {
+	report := junitTestSuites{Name: "pluto"}
+	suiteIndexes := make(map[string]int)
+
+	for _, o := range outputs {
+		// resources on apiVersions that are not in the versions list have no component
+		if o.APIVersion.Component != "" && !StringInSlice(o.APIVersion.Component, instance.Components) {
+			continue
+		}
+		suiteName := o.FilePath
+		if suiteName == "" {
+			suiteName = o.Namespace
+		}
+		if suiteName == "" {
+			suiteName = "pluto"
+		}
+		index, found := suiteIndexes[suiteName]
+		if !found {
+			index = len(report.Suites)
+			suiteIndexes[suiteName] = index
+			report.Suites = append(report.Suites, junitTestSuite{Name: suiteName})
+		}
+		suite := &report.Suites[index]
+
+		testCase := junitTestCase{
+			Name:      fmt.Sprintf("%s/%s", o.APIVersion.Kind, o.Name),
+			ClassName: o.APIVersion.Name,
+		}
+		switch {
+		case (o.Removed || o.Deprecated || o.TooNew) && o.Suppressed:
+			testCase.Skipped = &junitSkipped{
+				Message: fmt.Sprintf("%s %s is suppressed", o.APIVersion.Kind, o.APIVersion.Name),
+			}
+			suite.Skipped++
+		case (o.Removed || o.Deprecated || o.TooNew) && instance.Baseline != nil && instance.Baseline.Contains(o):
+			testCase.Skipped = &junitSkipped{
+				Message: fmt.Sprintf("%s %s is in the baseline", o.APIVersion.Kind, o.APIVersion.Name),
+			}
+			suite.Skipped++
+		case o.TooNew:
+			testCase.Failure = &junitFailure{
+				Message: fmt.Sprintf("%s %s is not available until %s", o.APIVersion.Kind, o.APIVersion.Name, o.APIVersion.IntroducedIn),
+				Type:    "too-new",
+			}
+			suite.Failures++
+		case o.Removed:
+			testCase.Failure = &junitFailure{
+				Message: junitMessage(o, "removed", o.APIVersion.RemovedIn),
+				Type:    "removed",
+			}
+			suite.Failures++
+		case o.Deprecated && !instance.OnlyShowRemoved:
+			testCase.Skipped = &junitSkipped{
+				Message: junitMessage(o, "deprecated", o.APIVersion.DeprecatedIn),
+			}
+			suite.Skipped++
+		}
+		suite.Tests++
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+
+	for _, suite := range report.Suites {
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Skipped += suite.Skipped
+	}
+
+	data, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+func junitMessage(o *Output, status string, in string) string {
+	v := o.APIVersion
+	message := fmt.Sprintf("%s %s is %s in %s", v.Kind, v.Name, status, in)
+	if v.ReplacementAPI != "" {
+		message = fmt.Sprintf("%s, use %s", message, v.ReplacementAPI)
+		if v.ReplacementAvailableIn != "" {
+			message = fmt.Sprintf("%s (available in %s)", message, v.ReplacementAvailableIn)
+		}
+	}
+	return message
+}
@@ -18,6 +18,7 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"sort"
 	"text/tabwriter"
@@ -38,6 +39,14 @@ type Output struct {
 	Name string `json:"name,omitempty" yaml:"name,omitempty"`
 	// FilePath is the full path of the file if the output came from a file
 	FilePath string `json:"filePath,omitempty" yaml:"filePath,omitempty"`
+	// Document is the position of the object in a multi-document file, starting at 1
+	Document int `json:"document,omitempty" yaml:"document,omitempty"`
+	// Line is the line of the apiVersion key of the object in the file
+	Line int `json:"line,omitempty" yaml:"line,omitempty"`
+	// Column is the column of the apiVersion key of the object in the file
+	Column int `json:"column,omitempty" yaml:"column,omitempty"`
+	// Cluster is the kube context that the object was found in, when more than one context is scanned
+	Cluster string `json:"cluster,omitempty" yaml:"cluster,omitempty"`
 	// Namespace is the namespace that the object is in
 	// The output may resolve this to UNKNOWN if there is no way of determining it
 	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
@@ -49,6 +58,22 @@ type Output struct {
 	Removed bool `json:"removed" yaml:"removed"`
 	// ReplacementAvailable is a boolean indicating whether or not the replacement is available
 	ReplacementAvailable bool `json:"replacementAvailable" yaml:"replacementAvailable"`
+	// Manager is the field manager that last wrote the object with this apiVersion, for
+	// outputs from the managedFields of an object in the cluster
+	Manager string `json:"manager,omitempty" yaml:"manager,omitempty"`
+	// User is the user that called the API, for outputs from an audit log
+	User string `json:"user,omitempty" yaml:"user,omitempty"`
+	// UserAgent is the user agent that called the API, for outputs from an audit log
+	UserAgent string `json:"userAgent,omitempty" yaml:"userAgent,omitempty"`
+	// Count is the number of calls to the API, for outputs from an audit log
+	Count int `json:"count,omitempty" yaml:"count,omitempty"`
+	// LastSeen is the time of the last call to the API in RFC3339 format, for outputs from an audit log
+	LastSeen string `json:"lastSeen,omitempty" yaml:"lastSeen,omitempty"`
+	// TooNew is a boolean indicating whether or not the version is introduced after the target version
+	TooNew bool `json:"tooNew,omitempty" yaml:"tooNew,omitempty"`
+	// Suppressed is true if the object has the pluto.fairwinds.com/ignore annotation or a
+	// pluto:ignore comment. Suppressed outputs do not affect the return code.
+	Suppressed bool `json:"suppressed,omitempty" yaml:"suppressed,omitempty"`
 	// CustomColumns is a list of column headers to be displayed with -ocustom or -omarkdown
 	CustomColumns []string `json:"-" yaml:"-"`
 }
@@ -56,9 +81,11 @@ type Output struct {
 // Instance is an instance of the API. This holds configuration for a "run" of Pluto
 type Instance struct {
 	Outputs                       []*Output         `json:"items,omitempty" yaml:"items,omitempty"`
+	Baselined                     []*Output         `json:"baselined,omitempty" yaml:"baselined,omitempty"`
 	IgnoreDeprecations            bool              `json:"-" yaml:"-"`
 	IgnoreRemovals                bool              `json:"-" yaml:"-"`
 	IgnoreUnavailableReplacements bool              `json:"-" yaml:"-"`
+	IgnoreTooNew                  bool              `json:"-" yaml:"-"`
 	OnlyShowRemoved               bool              `json:"-" yaml:"-"`
 	NoHeaders                     bool              `json:"-" yaml:"-"`
 	OutputFormat                  string            `json:"-" yaml:"-"`
@@ -66,54 +93,80 @@ type Instance struct {
 	DeprecatedVersions            []Version         `json:"-" yaml:"-"`
 	CustomColumns                 []string          `json:"-" yaml:"-"`
 	Components                    []string          `json:"-" yaml:"-"`
+	Baseline                      *Baseline         `json:"-" yaml:"-"`
 }
 
 // DisplayOutput prints the output based on desired variables
 func (instance *Instance) DisplayOutput() error {
+	return instance.WriteOutput(os.Stdout)
+}
+
+// WriteOutput writes the output to w in the OutputFormat of the instance. The outputs
+// are filtered first, see FilterOutput.
+func (instance *Instance) WriteOutput(w io.Writer) error {
 	if len(instance.Outputs) == 0 && (instance.OutputFormat == "normal" || instance.OutputFormat == "wide") {
-		fmt.Println("There were no resources found with known deprecated apiVersions.")
-		return nil
+		_, err := fmt.Fprintln(w, "There were no resources found with known deprecated apiVersions.")
+		return err
 	}
 
+	// junit reports every resource, not only the ones that are deprecated or removed
+	allOutputs := instance.Outputs
 	instance.FilterOutput()
 	var err error
 	var outData []byte
 	switch instance.OutputFormat {
 	case "normal":
 		c := instance.normalColumns()
-		t := instance.tabOut(c)
+		t := instance.tabOut(w, c)
 		err = t.Flush()
 		if err != nil {
 			return err
 		}
-		return nil
+		return instance.baselinedTabOut(w, c)
 	case "wide":
 		c := instance.wideColumns()
-		t := instance.tabOut(c)
+		t := instance.tabOut(w, c)
 		err = t.Flush()
 		if err != nil {
 			return err
 		}
-		return nil
+		return instance.baselinedTabOut(w, c)
 	case "custom":
 		c := instance.customColumns()
-		t := instance.tabOut(c)
+		t := instance.tabOut(w, c)
 		err = t.Flush()
 		if err != nil {
 			return err
 		}
+		return instance.baselinedTabOut(w, c)
 	case "json":
 		outData, err = json.Marshal(instance)
 		if err != nil {
 			return err
 		}
-		fmt.Println(string(outData))
+		_, err = fmt.Fprintln(w, string(outData))
+		return err
 	case "yaml":
 		outData, err = yaml.Marshal(instance)
 		if err != nil {
 			return err
 		}
-		fmt.Println(string(outData))
+		_, err = fmt.Fprintln(w, string(outData))
+		return err
+	case "sarif":
+		outData, err = instance.sarifOut()
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(outData))
+		return err
+	case "junit":
+		outData, err = instance.junitOut(allOutputs)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(outData))
+		return err
 	case "markdown":
 		var c columnList
 		if len(instance.CustomColumns) >= 1 {
@@ -121,10 +174,18 @@ func (instance *Instance) DisplayOutput() error {
 		} else {
 			c = instance.wideColumns()
 		}
-		t := instance.markdownOut(c)
+		t := instance.markdownOut(w, c)
 		if t != nil {
 			t.Render()
 		}
+		if len(instance.Baselined) > 0 {
+			baselined := *instance
+			baselined.Outputs = instance.Baselined
+			_, _ = fmt.Fprintf(w, "\n%s\n", baselinedHeader)
+			if t := baselined.markdownOut(w, c); t != nil {
+				t.Render()
+			}
+		}
 	case "csv":
 		var c columnList
 		if len(instance.CustomColumns) >= 1 {
@@ -132,7 +193,7 @@ func (instance *Instance) DisplayOutput() error {
 		} else {
 			c = instance.wideColumns()
 		}
-		csvWriter, err := instance.csvOut(c)
+		csvWriter, err := instance.csvOut(w, instance.withBaselinedColumn(c))
 		if err != nil {
 			return err
 		}
@@ -147,6 +208,15 @@ func (instance *Instance) DisplayOutput() error {
 	return nil
 }
 
+// SetOutputStatus sets Deprecated, Removed, ReplacementAvailable and TooNew on an
+// output for the target versions
+func (instance *Instance) SetOutputStatus(output *Output) {
+	output.Deprecated = output.APIVersion.isDeprecatedIn(instance.TargetVersions)
+	output.Removed = output.APIVersion.isRemovedIn(instance.TargetVersions)
+	output.ReplacementAvailable = output.APIVersion.isReplacementAvailableIn(instance.TargetVersions)
+	output.TooNew = output.APIVersion.isTooNewIn(instance.TargetVersions)
+}
+
 // FilterOutput filters the outputs that get printed
 // first it fills out the Deprecated and Removed booleans
 // then it returns the outputs that are either deprecated or removed
@@ -155,32 +225,59 @@ func (instance *Instance) DisplayOutput() error {
 // apiVersions that are deprecated but not removed
 func (instance *Instance) FilterOutput() {
 	var usableOutputs []*Output
-	for _, output := range instance.Outputs {
-		output.Deprecated = output.APIVersion.isDeprecatedIn(instance.TargetVersions)
-		output.Removed = output.APIVersion.isRemovedIn(instance.TargetVersions)
-		output.ReplacementAvailable = output.APIVersion.isReplacementAvailableIn(instance.TargetVersions)
+	var baselined []*Output
+	candidates := append(append([]*Output{}, instance.Outputs...), instance.Baselined...)
+	for _, output := range candidates {
+		instance.SetOutputStatus(output)
 		switch instance.OnlyShowRemoved {
 		case false:
-			if output.Deprecated || output.Removed {
+			if output.Deprecated || output.Removed || output.TooNew {
 				if StringInSlice(output.APIVersion.Component, instance.Components) {
 					usableOutputs = append(usableOutputs, output)
 				}
 			}
 		case true:
-			if output.Removed {
+			// resources that are too new fail to apply just like removed ones
+			if output.Removed || output.TooNew {
 				if StringInSlice(output.APIVersion.Component, instance.Components) {
 					usableOutputs = append(usableOutputs, output)
 				}
 			}
 		}
 	}
+	if instance.Baseline != nil {
+		var newOutputs []*Output
+		for _, output := range usableOutputs {
+			if instance.Baseline.Contains(output) {
+				baselined = append(baselined, output)
+			} else {
+				newOutputs = append(newOutputs, output)
+			}
+		}
+		usableOutputs = newOutputs
+	}
 	instance.Outputs = usableOutputs
+	instance.Baselined = baselined
+}
+
+// baselinedTabOut writes the outputs that are in the baseline in a separate table
+func (instance *Instance) baselinedTabOut(out io.Writer, columns columnList) error {
+	if len(instance.Baselined) == 0 {
+		return nil
+	}
+	if len(instance.Outputs) == 0 {
+		_, _ = fmt.Fprintln(out)
+	}
+	baselined := *instance
+	baselined.Outputs = instance.Baselined
+	_, _ = fmt.Fprintln(out, baselinedHeader)
+	return baselined.tabOut(out, columns).Flush()
 }
 
 // removeDeprecatedOnly is a list replacement operation
-func (instance *Instance) tabOut(columns columnList) *tabwriter.Writer {
+func (instance *Instance) tabOut(out io.Writer, columns columnList) *tabwriter.Writer {
 	w := new(tabwriter.Writer)
-	w.Init(os.Stdout, 0, 15, 2, padChar, 0)
+	w.Init(out, 0, 15, 2, padChar, 0)
 
 	if len(instance.Outputs) == 0 {
 		_, _ = fmt.Fprintln(w, "No output to display")
@@ -223,15 +320,15 @@ func (instance *Instance) tabOut(columns columnList) *tabwriter.Writer {
 	return w
 }
 
-func (instance *Instance) markdownOut(columns columnList) *tablewriter.Table {
+func (instance *Instance) markdownOut(out io.Writer, columns columnList) *tablewriter.Table {
 	table := tablewriter.NewTable(
-		os.Stdout,
+		out,
 		tablewriter.WithRenderer(renderer.NewMarkdown()),
 		tablewriter.WithHeaderAlignment(tw.AlignNone), // retain parity with previous versions
 	)
 
 	if len(instance.Outputs) == 0 {
-		_, _ = fmt.Println("No output to display")
+		_, _ = fmt.Fprintln(out, "No output to display")
 		return nil
 	}
 
@@ -262,11 +359,14 @@ func (instance *Instance) markdownOut(columns columnList) *tablewriter.Table {
 	return table
 }
 
-func (instance *Instance) csvOut(columns columnList) (*csv.Writer, error) {
-	csvWriter := csv.NewWriter(os.Stdout)
+// csvOut writes the outputs as csv. Baselined outputs are in the same table, since a
+// csv file only has one, and the BASELINED column tells them apart.
+func (instance *Instance) csvOut(out io.Writer, columns columnList) (*csv.Writer, error) {
+	csvWriter := csv.NewWriter(out)
 
-	if len(instance.Outputs) == 0 {
-		_, _ = fmt.Println("No output to display")
+	outputs := append(append([]*Output{}, instance.Outputs...), instance.Baselined...)
+	if len(outputs) == 0 {
+		_, _ = fmt.Fprintln(out, "No output to display")
 	}
 
 	columnIndexes := make([]int, 0, len(columns))
@@ -286,7 +386,7 @@ func (instance *Instance) csvOut(columns columnList) (*csv.Writer, error) {
 		csvData = append(csvData, headers)
 	}
 
-	for _, o := range instance.Outputs {
+	for _, o := range outputs {
 		var row []string
 		for _, k := range columnIndexes {
 			row = append(row, columns[k].value(o))
@@ -308,23 +408,33 @@ func (instance *Instance) csvOut(columns columnList) (*csv.Writer, error) {
 // exit 2 - version deprecated
 // exit 3 - version removed
 // exit 4 - replacement is unavailable in target version
+// exit 5 - version is not available yet in target version
 func (instance *Instance) GetReturnCode() int {
 	returnCode := 0
 	var deprecations int
 	var removals int
 	var unavailableReplacements int
+	var tooNew int
 	for _, output := range instance.Outputs {
-		if output.APIVersion.isRemovedIn(instance.TargetVersions) {
+		if output.Suppressed {
+			continue
+		}
+		removed := output.APIVersion.isRemovedIn(instance.TargetVersions)
+		deprecated := output.APIVersion.isDeprecatedIn(instance.TargetVersions)
+		if removed {
 			removals = removals + 1
 		}
-		if output.APIVersion.isDeprecatedIn(instance.TargetVersions) {
+		if deprecated {
 			if output.APIVersion.isReplacementAvailableIn(instance.TargetVersions) || !instance.IgnoreUnavailableReplacements {
 				deprecations = deprecations + 1
 			}
 		}
-		if !output.APIVersion.isReplacementAvailableIn(instance.TargetVersions) {
+		if (deprecated || removed) && !output.APIVersion.isReplacementAvailableIn(instance.TargetVersions) {
 			unavailableReplacements = unavailableReplacements + 1
 		}
+		if output.APIVersion.isTooNewIn(instance.TargetVersions) {
+			tooNew = tooNew + 1
+		}
 	}
 
 	if deprecations > 0 && !instance.IgnoreDeprecations {
@@ -336,5 +446,8 @@ func (instance *Instance) GetReturnCode() int {
 	if unavailableReplacements > 0 && !instance.IgnoreUnavailableReplacements {
 		returnCode = 4
 	}
+	if tooNew > 0 && !instance.IgnoreTooNew {
+		returnCode = 5
+	}
 	return returnCode
 }
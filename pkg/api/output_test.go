@@ -29,6 +29,7 @@
 package api
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -113,6 +114,78 @@ func ExampleInstance_DisplayOutput_normal() {
 	// deprecated not removed-- Deployment-- apps/v1------------- none--------- false---- true-------- false-------
 }
 
+func ExampleInstance_DisplayOutput_baseline() {
+	instance := &Instance{
+		TargetVersions: map[string]string{
+			"foo": "v1.16.0",
+		},
+		Outputs: []*Output{
+			testOutput1,
+			testOutput2,
+		},
+		OutputFormat: "normal",
+		Components:   []string{"foo"},
+		Baseline:     NewBaseline([]*Output{testOutput2}),
+	}
+	_ = instance.DisplayOutput()
+
+	// Output:
+	// NAME----------- KIND-------- VERSION------------- REPLACEMENT-- REMOVED-- DEPRECATED-- REPL AVAIL--
+	// some name one-- Deployment-- extensions/v1beta1-- apps/v1------ true----- true-------- true--------
+	//
+	// Baselined findings (not counted in the exit code):
+	// NAME----------- KIND-------- VERSION------------- REPLACEMENT-- REMOVED-- DEPRECATED-- REPL AVAIL--
+	// some name two-- Deployment-- extensions/v1beta1-- apps/v1------ true----- true-------- true--------
+}
+
+func ExampleInstance_DisplayOutput_baseline_csv() {
+	instance := &Instance{
+		TargetVersions: map[string]string{
+			"foo": "v1.16.0",
+		},
+		Outputs: []*Output{
+			testOutput1,
+			testOutput2,
+		},
+		OutputFormat:  "csv",
+		CustomColumns: []string{"NAME", "VERSION"},
+		Components:    []string{"foo"},
+		Baseline:      NewBaseline([]*Output{testOutput2}),
+	}
+	_ = instance.DisplayOutput()
+
+	// Output:
+	// NAME,VERSION,BASELINED
+	// some name one,extensions/v1beta1,false
+	// some name two,extensions/v1beta1,true
+}
+
+func ExampleInstance_DisplayOutput_auditLog() {
+	instance := &Instance{
+		TargetVersions: map[string]string{
+			"foo": "v1.16.0",
+		},
+		Outputs: []*Output{
+			{
+				Name:       "admin",
+				Namespace:  "default",
+				APIVersion: testOutput1.APIVersion,
+				User:       "admin",
+				UserAgent:  "kubectl/v1.15.0",
+				Count:      3,
+				LastSeen:   "2022-05-01T11:00:00Z",
+			},
+		},
+		OutputFormat: "normal",
+		Components:   []string{"foo"},
+	}
+	_ = instance.DisplayOutput()
+
+	// Output:
+	// NAME--- KIND-------- VERSION------------- REPLACEMENT-- REMOVED-- DEPRECATED-- REPL AVAIL-- NAMESPACE-- USER AGENT------- COUNT--
+	// admin-- Deployment-- extensions/v1beta1-- apps/v1------ true----- true-------- true-------- default---- kubectl/v1.15.0-- 3------
+}
+
 func ExampleInstance_DisplayOutput_onlyShowRemoved() {
 	instance := &Instance{
 		TargetVersions: map[string]string{
@@ -155,6 +228,30 @@ func ExampleInstance_DisplayOutput_wide() {
 	// some name two-- <UNKNOWN>-------- Deployment-- extensions/v1beta1-- apps/v1------ true-------- v1.9.0--------- true----- v1.16.0----- true-------- v1.10.0--------
 }
 
+func ExampleInstance_DisplayOutput_clusters() {
+	staging := *testOutput2
+	staging.Cluster = "staging"
+	production := *testOutput2
+	production.Cluster = "production"
+	instance := &Instance{
+		TargetVersions: map[string]string{
+			"foo": "v1.16.0",
+		},
+		Outputs: []*Output{
+			&production,
+			&staging,
+		},
+		OutputFormat: "normal",
+		Components:   []string{"foo"},
+	}
+	_ = instance.DisplayOutput()
+
+	// Output:
+	// CLUSTER----- NAME----------- KIND-------- VERSION------------- REPLACEMENT-- REMOVED-- DEPRECATED-- REPL AVAIL--
+	// production-- some name two-- Deployment-- extensions/v1beta1-- apps/v1------ true----- true-------- true--------
+	// staging----- some name two-- Deployment-- extensions/v1beta1-- apps/v1------ true----- true-------- true--------
+}
+
 func ExampleInstance_DisplayOutput_custom() {
 	instance := &Instance{
 		TargetVersions: map[string]string{
@@ -176,6 +273,34 @@ func ExampleInstance_DisplayOutput_custom() {
 	// <UNKNOWN>-------- some name two-- v1.9.0--------- true-------- apps/v1------ extensions/v1beta1-- Deployment-- foo-------- <UNKNOWN>-----
 }
 
+func ExampleInstance_DisplayOutput_customPosition() {
+	instance := &Instance{
+		TargetVersions: map[string]string{
+			"foo": "v1.16.0",
+		},
+		Outputs: []*Output{
+			{
+				Name:       "positioned",
+				FilePath:   "path-to-file",
+				Document:   2,
+				Line:       12,
+				Column:     3,
+				APIVersion: testOutput1.APIVersion,
+			},
+			testOutput2,
+		},
+		OutputFormat:  "custom",
+		Components:    []string{"foo"},
+		CustomColumns: []string{"NAME", "FILEPATH", "LINE", "COLUMN"},
+	}
+	_ = instance.DisplayOutput()
+
+	// Output:
+	// NAME----------- FILEPATH------ LINE------- COLUMN-----
+	// positioned----- path-to-file-- 12--------- 3----------
+	// some name two-- <UNKNOWN>----- <UNKNOWN>-- <UNKNOWN>--
+}
+
 func ExampleInstance_DisplayOutput_markdown() {
 	instance := &Instance{
 		TargetVersions: map[string]string{
@@ -283,6 +408,182 @@ func ExampleInstance_DisplayOutput_yaml() {
 	//     foo: v1.16.0
 }
 
+func ExampleInstance_DisplayOutput_sarif() {
+	instance := &Instance{
+		TargetVersions: map[string]string{
+			"foo": "v1.16.0",
+		},
+		Outputs: []*Output{
+			{
+				Name:       "positioned",
+				FilePath:   "path-to-file",
+				Line:       12,
+				Column:     1,
+				APIVersion: testOutput1.APIVersion,
+			},
+			testOutput2,
+			testOutputDeprecatedNotRemoved,
+		},
+		Components:   []string{"foo"},
+		OutputFormat: "sarif",
+	}
+	_ = instance.DisplayOutput()
+
+	// Output:
+	// {
+	//   "$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+	//   "version": "2.1.0",
+	//   "runs": [
+	//     {
+	//       "tool": {
+	//         "driver": {
+	//           "name": "pluto",
+	//           "informationUri": "https://github.com/FairwindsOps/pluto",
+	//           "rules": [
+	//             {
+	//               "id": "Deployment/extensions/v1beta1",
+	//               "name": "RemovedAPIVersion",
+	//               "shortDescription": {
+	//                 "text": "Deployment extensions/v1beta1 is removed"
+	//               },
+	//               "fullDescription": {
+	//                 "text": "Deployment extensions/v1beta1 is deprecated in v1.9.0, removed in v1.16.0, replaced by apps/v1 (available in v1.10.0)."
+	//               },
+	//               "defaultConfiguration": {
+	//                 "level": "error"
+	//               },
+	//               "properties": {
+	//                 "component": "foo"
+	//               }
+	//             },
+	//             {
+	//               "id": "Deployment/apps/v1",
+	//               "name": "DeprecatedAPIVersion",
+	//               "shortDescription": {
+	//                 "text": "Deployment apps/v1 is deprecated"
+	//               },
+	//               "fullDescription": {
+	//                 "text": "Deployment apps/v1 is deprecated in v1.16.0, replaced by none."
+	//               },
+	//               "defaultConfiguration": {
+	//                 "level": "note"
+	//               },
+	//               "properties": {
+	//                 "component": "foo"
+	//               }
+	//             }
+	//           ]
+	//         }
+	//       },
+	//       "results": [
+	//         {
+	//           "ruleId": "Deployment/extensions/v1beta1",
+	//           "ruleIndex": 0,
+	//           "level": "error",
+	//           "message": {
+	//             "text": "Deployment positioned uses extensions/v1beta1, which is removed in v1.16.0. Use apps/v1 instead."
+	//           },
+	//           "locations": [
+	//             {
+	//               "physicalLocation": {
+	//                 "artifactLocation": {
+	//                   "uri": "path-to-file"
+	//                 },
+	//                 "region": {
+	//                   "startLine": 12,
+	//                   "startColumn": 1
+	//                 }
+	//               }
+	//             }
+	//           ]
+	//         },
+	//         {
+	//           "ruleId": "Deployment/extensions/v1beta1",
+	//           "ruleIndex": 0,
+	//           "level": "error",
+	//           "message": {
+	//             "text": "Deployment some name two uses extensions/v1beta1, which is removed in v1.16.0. Use apps/v1 instead."
+	//           }
+	//         },
+	//         {
+	//           "ruleId": "Deployment/apps/v1",
+	//           "ruleIndex": 1,
+	//           "level": "note",
+	//           "message": {
+	//             "text": "Deployment deprecated not removed uses apps/v1, which is deprecated in v1.16.0. Use none instead."
+	//           }
+	//         }
+	//       ]
+	//     }
+	//   ]
+	// }
+}
+
+func ExampleInstance_DisplayOutput_junit() {
+	instance := &Instance{
+		TargetVersions: map[string]string{
+			"foo": "v1.16.0",
+		},
+		Outputs: []*Output{
+			testOutput1,
+			testOutput2,
+			testOutputNoOutput,
+			testOutputDeprecatedNotRemoved,
+		},
+		Components:   []string{"foo"},
+		OutputFormat: "junit",
+	}
+	_ = instance.DisplayOutput()
+
+	// Output:
+	// <?xml version="1.0" encoding="UTF-8"?>
+	// <testsuites name="pluto" tests="4" failures="2" skipped="1">
+	//   <testsuite name="path-to-file" tests="1" failures="1" skipped="0">
+	//     <testcase name="Deployment/some name one" classname="extensions/v1beta1">
+	//       <failure message="Deployment extensions/v1beta1 is removed in v1.16.0, use apps/v1 (available in v1.10.0)" type="removed"></failure>
+	//     </testcase>
+	//   </testsuite>
+	//   <testsuite name="pluto" tests="3" failures="1" skipped="1">
+	//     <testcase name="Deployment/some name two" classname="extensions/v1beta1">
+	//       <failure message="Deployment extensions/v1beta1 is removed in v1.16.0, use apps/v1 (available in v1.10.0)" type="removed"></failure>
+	//     </testcase>
+	//     <testcase name="Deployment/not a deprecated object" classname="apps/v1"></testcase>
+	//     <testcase name="Deployment/deprecated not removed" classname="apps/v1">
+	//       <skipped message="Deployment apps/v1 is deprecated in v1.16.0, use none"></skipped>
+	//     </testcase>
+	//   </testsuite>
+	// </testsuites>
+}
+
+func ExampleInstance_DisplayOutput_junitScanned() {
+	instance := &Instance{
+		TargetVersions:     map[string]string{"k8s": "v1.16.0"},
+		DeprecatedVersions: []Version{testVersionDeployment},
+		Components:         []string{"k8s"},
+		OutputFormat:       "junit",
+	}
+	instance.Outputs, _ = instance.IsVersioned([]byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: current
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+`))
+	_ = instance.DisplayOutput()
+
+	// Output:
+	// <?xml version="1.0" encoding="UTF-8"?>
+	// <testsuites name="pluto" tests="2" failures="0" skipped="0">
+	//   <testsuite name="pluto" tests="2" failures="0" skipped="0">
+	//     <testcase name="Deployment/current" classname="apps/v1"></testcase>
+	//     <testcase name="ConfigMap/settings" classname="v1"></testcase>
+	//   </testsuite>
+	// </testsuites>
+}
+
 func ExampleInstance_DisplayOutput_csv() {
 	instance := &Instance{
 		TargetVersions: map[string]string{
@@ -373,6 +674,30 @@ func ExampleInstance_DisplayOutput_zeroLength() {
 	// Output: There were no resources found with known deprecated apiVersions.
 }
 
+func TestInstance_WriteOutput(t *testing.T) {
+	instance := &Instance{
+		TargetVersions: map[string]string{
+			"foo": "v1.16.0",
+		},
+		Outputs: []*Output{
+			testOutput1,
+			testOutputNoOutput,
+		},
+		OutputFormat: "csv",
+		NoHeaders:    true,
+		Components:   []string{"foo"},
+	}
+	var out bytes.Buffer
+	assert.NoError(t, instance.WriteOutput(&out))
+	assert.Equal(t, "some name one,pluto-namespace,Deployment,extensions/v1beta1,apps/v1,true,v1.9.0,true,v1.16.0,true,v1.10.0\n", out.String())
+
+	out.Reset()
+	instance.Outputs = nil
+	instance.OutputFormat = "normal"
+	assert.NoError(t, instance.WriteOutput(&out))
+	assert.Equal(t, "There were no resources found with known deprecated apiVersions.\n", out.String())
+}
+
 func TestGetReturnCode(t *testing.T) {
 
 	type args struct {
@@ -380,6 +705,7 @@ func TestGetReturnCode(t *testing.T) {
 		ignoreDeprecations           bool
 		ignoreRemovals               bool
 		ignoreReplacementUnavailable bool
+		ignoreTooNew                 bool
 	}
 	tests := []struct {
 		name string
@@ -414,6 +740,52 @@ func TestGetReturnCode(t *testing.T) {
 			},
 			want: 4,
 		},
+		{
+			name: "version is too new",
+			args: args{
+				outputs: []*Output{
+					{
+						APIVersion: &Version{
+							IntroducedIn: "v1.17.0",
+							Component:    "foo",
+						},
+					},
+				},
+			},
+			want: 5,
+		},
+		{
+			name: "version is too new but ignored",
+			args: args{
+				outputs: []*Output{
+					{
+						APIVersion: &Version{
+							IntroducedIn: "v1.17.0",
+							Component:    "foo",
+						},
+					},
+				},
+				ignoreTooNew: true,
+			},
+			want: 0,
+		},
+		{
+			name: "version is removed but suppressed",
+			args: args{
+				outputs: []*Output{
+					{
+						APIVersion: &Version{
+							DeprecatedIn:           "v1.9.0",
+							RemovedIn:              "v1.16.0",
+							ReplacementAvailableIn: "v1.10.0",
+							Component:              "foo",
+						},
+						Suppressed: true,
+					},
+				},
+			},
+			want: 0,
+		},
 		{
 			name: "version is deprecated ignore deprecations",
 			args: args{
@@ -516,6 +888,7 @@ func TestGetReturnCode(t *testing.T) {
 				IgnoreDeprecations:            tt.args.ignoreDeprecations,
 				IgnoreRemovals:                tt.args.ignoreRemovals,
 				IgnoreUnavailableReplacements: tt.args.ignoreReplacementUnavailable,
+				IgnoreTooNew:                  tt.args.ignoreTooNew,
 				Outputs:                       tt.args.outputs,
 			}
 			got := instance.GetReturnCode()
@@ -523,3 +896,94 @@ func TestGetReturnCode(t *testing.T) {
 		})
 	}
 }
+
+func Test_newSarifRule(t *testing.T) {
+	version := &Version{Name: "apps/v1", Kind: "Deployment", Component: "foo"}
+	tests := []struct {
+		name      string
+		output    *Output
+		wantName  string
+		wantShort string
+		wantLevel string
+	}{
+		{
+			name:      "removed",
+			output:    &Output{APIVersion: version, Removed: true, Deprecated: true},
+			wantName:  "RemovedAPIVersion",
+			wantShort: "Deployment apps/v1 is removed",
+			wantLevel: "error",
+		},
+		{
+			name:      "deprecated",
+			output:    &Output{APIVersion: version, Deprecated: true, ReplacementAvailable: true},
+			wantName:  "DeprecatedAPIVersion",
+			wantShort: "Deployment apps/v1 is deprecated",
+			wantLevel: "warning",
+		},
+		{
+			name:      "deprecated without a replacement",
+			output:    &Output{APIVersion: version, Deprecated: true},
+			wantName:  "DeprecatedAPIVersion",
+			wantShort: "Deployment apps/v1 is deprecated",
+			wantLevel: "note",
+		},
+		{
+			name:      "too new",
+			output:    &Output{APIVersion: version, TooNew: true},
+			wantName:  "UnavailableAPIVersion",
+			wantShort: "Deployment apps/v1 is not available yet",
+			wantLevel: "error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := newSarifRule("Deployment/apps/v1", tt.output)
+			assert.Equal(t, tt.wantName, rule.Name)
+			assert.Equal(t, tt.wantShort, rule.ShortDescription.Text)
+			assert.Equal(t, tt.wantLevel, rule.DefaultConfiguration.Level)
+		})
+	}
+}
+
+func TestInstance_IsVersioned_everyResource(t *testing.T) {
+	data := []byte("apiVersion: extensions/v1beta1\nkind: Deployment\nmetadata:\n  name: old\n---\napiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: current\n---\nfoo: bar\n")
+	instance := &Instance{
+		TargetVersions:     map[string]string{"k8s": "v1.16.0"},
+		DeprecatedVersions: []Version{testVersionDeployment},
+		Components:         []string{"k8s"},
+		OutputFormat:       "junit",
+	}
+	outputs, err := instance.IsVersioned(data)
+	assert.NoError(t, err)
+	if assert.Len(t, outputs, 2) {
+		assert.Equal(t, "old", outputs[0].Name)
+		assert.Equal(t, &Version{Name: "apps/v1", Kind: "Deployment"}, outputs[1].APIVersion)
+	}
+
+	// the resource is not reported in other formats
+	instance.Outputs = outputs
+	instance.FilterOutput()
+	assert.Len(t, instance.Outputs, 1)
+
+	instance.OutputFormat = "normal"
+	outputs, err = instance.IsVersioned(data)
+	assert.NoError(t, err)
+	assert.Len(t, outputs, 1)
+}
+
+func TestInstance_withColumns_keepsOutputs(t *testing.T) {
+	// the spare capacity of the outputs must not be written to
+	outputs := make([]*Output, 1, 2)
+	outputs[0] = testOutput1
+	spare := outputs[:2]
+	spare[1] = testOutput2
+
+	instance := &Instance{
+		Outputs:   outputs,
+		Baselined: []*Output{{Name: "baselined", Cluster: "production", Count: 1, APIVersion: testOutput1.APIVersion}},
+	}
+	columns := instance.withClusterColumn(instance.withAuditColumns(instance.normalColumns(), new(count)))
+	assert.Equal(t, "CLUSTER", columns[0].header())
+	assert.Equal(t, "COUNT", columns[len(columns)-1].header())
+	assert.Same(t, testOutput2, spare[1])
+}
@@ -0,0 +1,184 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"golang.org/x/mod/semver"
+	"gopkg.in/yaml.v3"
+)
+
+// planComponent is the component that upgrade plans are made for
+const planComponent = "k8s"
+
+// Plan is an upgrade from one Kubernetes minor version to another, one minor version at a time
+type Plan struct {
+	From string    `json:"from" yaml:"from"`
+	To   string    `json:"to" yaml:"to"`
+	Hops []PlanHop `json:"hops" yaml:"hops"`
+}
+
+// PlanHop is an upgrade of a single minor version
+type PlanHop struct {
+	From string `json:"from" yaml:"from"`
+	To   string `json:"to" yaml:"to"`
+	// Migrations are the resources that are removed or become deprecated in the To version
+	Migrations []PlanMigration `json:"migrations,omitempty" yaml:"migrations,omitempty"`
+}
+
+// PlanMigration is a resource that needs to be migrated to a new apiVersion
+type PlanMigration struct {
+	Name       string   `json:"name,omitempty" yaml:"name,omitempty"`
+	Namespace  string   `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	FilePath   string   `json:"filePath,omitempty" yaml:"filePath,omitempty"`
+	APIVersion *Version `json:"api" yaml:"api"`
+	// Removed is true if the apiVersion is removed in the hop, which means the resource
+	// must be migrated before the hop. Otherwise the apiVersion becomes deprecated.
+	Removed bool `json:"removed" yaml:"removed"`
+	// ReplacementAvailableAtStart is true if the replacement is available in the version
+	// the plan starts from, so the resource can be migrated before upgrading at all
+	ReplacementAvailableAtStart bool `json:"replacementAvailableAtStart" yaml:"replacementAvailableAtStart"`
+}
+
+// Plan evaluates the outputs at every minor version between from and to. Versions
+// can be written without a patch version, like v1.22. Only the k8s component is planned.
+func (instance *Instance) Plan(from string, to string) (*Plan, error) {
+	fromMajor, fromMinor, err := parseMinorVersion(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --from version: %w", err)
+	}
+	toMajor, toMinor, err := parseMinorVersion(to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --to version: %w", err)
+	}
+	if fromMajor != toMajor {
+		return nil, fmt.Errorf("cannot plan an upgrade across major versions %s and %s", from, to)
+	}
+	if toMinor <= fromMinor {
+		return nil, fmt.Errorf("the version to upgrade to, %s, must be after %s", to, from)
+	}
+
+	start := map[string]string{planComponent: minorVersion(fromMajor, fromMinor)}
+	plan := &Plan{
+		From: start[planComponent],
+		To:   minorVersion(toMajor, toMinor),
+	}
+	for minor := fromMinor; minor < toMinor; minor++ {
+		hopFrom := map[string]string{planComponent: minorVersion(fromMajor, minor)}
+		hopTo := map[string]string{planComponent: minorVersion(fromMajor, minor+1)}
+		hop := PlanHop{From: hopFrom[planComponent], To: hopTo[planComponent]}
+		for _, o := range instance.Outputs {
+			v := o.APIVersion
+			if v.Component != planComponent || o.Suppressed {
+				continue
+			}
+			migration := PlanMigration{
+				Name:                        o.Name,
+				Namespace:                   o.Namespace,
+				FilePath:                    o.FilePath,
+				APIVersion:                  v,
+				ReplacementAvailableAtStart: v.isReplacementAvailableIn(start),
+			}
+			switch {
+			case v.isRemovedIn(hopTo) && (!v.isRemovedIn(hopFrom) || minor == fromMinor):
+				// anything that is already removed has to be migrated before the first hop
+				migration.Removed = true
+			case v.isDeprecatedIn(hopTo) && !v.isDeprecatedIn(hopFrom):
+				migration.Removed = false
+			default:
+				continue
+			}
+			hop.Migrations = append(hop.Migrations, migration)
+		}
+		plan.Hops = append(plan.Hops, hop)
+	}
+	return plan, nil
+}
+
+// DisplayPlan writes the plan to out in the output format of the instance
+func (instance *Instance) DisplayPlan(out io.Writer, plan *Plan) error {
+	switch instance.OutputFormat {
+	case "json":
+		outData, err := json.Marshal(plan)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintln(out, string(outData))
+	case "yaml":
+		outData, err := yaml.Marshal(plan)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintln(out, string(outData))
+	case "normal", "wide":
+		for _, hop := range plan.Hops {
+			if len(hop.Migrations) == 0 {
+				_, _ = fmt.Fprintf(out, "%s -> %s: no resources need to be migrated\n\n", hop.From, hop.To)
+				continue
+			}
+			_, _ = fmt.Fprintf(out, "%s -> %s:\n", hop.From, hop.To)
+			w := new(tabwriter.Writer)
+			w.Init(out, 0, 15, 2, padChar, 0)
+			if !instance.NoHeaders {
+				_, _ = fmt.Fprintf(w, "NAME\t NAMESPACE\t KIND\t VERSION\t REPLACEMENT\t ACTION\t REPL AVAIL IN %s\t\n", plan.From)
+			}
+			for _, m := range hop.Migrations {
+				action := "deprecated"
+				if m.Removed {
+					action = "migrate before upgrade"
+				}
+				namespace := m.Namespace
+				if namespace == "" {
+					namespace = "<UNKNOWN>"
+				}
+				_, _ = fmt.Fprintf(w, "%s\t %s\t %s\t %s\t %s\t %s\t %t\t\n", m.Name, namespace, m.APIVersion.Kind, m.APIVersion.Name, m.APIVersion.ReplacementAPI, action, m.ReplacementAvailableAtStart)
+			}
+			_, _ = fmt.Fprintln(w)
+			if err := w.Flush(); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("plan does not support the %s output format, use normal, json or yaml", instance.OutputFormat)
+	}
+	return nil
+}
+
+// parseMinorVersion returns the major and minor version of a semver string like v1.22 or v1.22.3
+func parseMinorVersion(version string) (int, int, error) {
+	if !semver.IsValid(version) {
+		return 0, 0, fmt.Errorf("%s is not valid semver with a leading 'v'", version)
+	}
+	parts := strings.Split(strings.TrimPrefix(semver.MajorMinor(version), "v"), ".")
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, err
+	}
+	return major, minor, nil
+}
+
+func minorVersion(major int, minor int) string {
+	return fmt.Sprintf("v%d.%d.0", major, minor)
+}
@@ -0,0 +1,110 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var planIngress = &Output{
+	Name:      "webapp",
+	Namespace: "web",
+	APIVersion: &Version{
+		Name:                   "networking.k8s.io/v1beta1",
+		Kind:                   "Ingress",
+		DeprecatedIn:           "v1.19.0",
+		RemovedIn:              "v1.22.0",
+		ReplacementAPI:         "networking.k8s.io/v1",
+		ReplacementAvailableIn: "v1.19.0",
+		Component:              "k8s",
+	},
+}
+
+var planPodSecurityPolicy = &Output{
+	Name: "psp",
+	APIVersion: &Version{
+		Name:         "policy/v1beta1",
+		Kind:         "PodSecurityPolicy",
+		DeprecatedIn: "v1.21.0",
+		RemovedIn:    "v1.25.0",
+		Component:    "k8s",
+	},
+}
+
+func TestInstance_Plan(t *testing.T) {
+	instance := &Instance{
+		Outputs: []*Output{
+			planIngress,
+			planPodSecurityPolicy,
+			{Name: "suppressed", APIVersion: planIngress.APIVersion, Suppressed: true},
+			{Name: "other component", APIVersion: &Version{Name: "foo/v1", Kind: "Foo", RemovedIn: "v1.21.0", Component: "foo"}},
+		},
+	}
+
+	got, err := instance.Plan("v1.20", "v1.22.3")
+	assert.NoError(t, err)
+	assert.Equal(t, &Plan{
+		From: "v1.20.0",
+		To:   "v1.22.0",
+		Hops: []PlanHop{
+			{
+				From: "v1.20.0",
+				To:   "v1.21.0",
+				Migrations: []PlanMigration{
+					{Name: "psp", APIVersion: planPodSecurityPolicy.APIVersion},
+				},
+			},
+			{
+				From: "v1.21.0",
+				To:   "v1.22.0",
+				Migrations: []PlanMigration{
+					{Name: "webapp", Namespace: "web", APIVersion: planIngress.APIVersion, Removed: true, ReplacementAvailableAtStart: true},
+				},
+			},
+		},
+	}, got)
+
+	got, err = instance.Plan("v1.23", "v1.24")
+	assert.NoError(t, err)
+	assert.Equal(t, []PlanMigration{
+		{Name: "webapp", Namespace: "web", APIVersion: planIngress.APIVersion, Removed: true, ReplacementAvailableAtStart: true},
+	}, got.Hops[0].Migrations, "resources that are already removed are migrated before the first hop")
+
+	_, err = instance.Plan("v1.22", "v1.22")
+	assert.Error(t, err)
+	_, err = instance.Plan("v1.22", "v2.0")
+	assert.Error(t, err)
+	_, err = instance.Plan("1.22", "v1.23")
+	assert.Error(t, err)
+}
+
+func ExampleInstance_DisplayPlan() {
+	instance := &Instance{
+		Outputs:      []*Output{planIngress, planPodSecurityPolicy},
+		OutputFormat: "normal",
+	}
+	plan, _ := instance.Plan("v1.21", "v1.23")
+	_ = instance.DisplayPlan(os.Stdout, plan)
+
+	// Output:
+	// v1.21.0 -> v1.22.0:
+	// NAME---- NAMESPACE-- KIND----- VERSION-------------------- REPLACEMENT----------- ACTION------------------ REPL AVAIL IN v1.21.0--
+	// webapp-- web-------- Ingress-- networking.k8s.io/v1beta1-- networking.k8s.io/v1-- migrate before upgrade-- true-------------------
+	//
+	// v1.22.0 -> v1.23.0: no resources need to be migrated
+}
@@ -0,0 +1,248 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	plutoURI     = "https://github.com/FairwindsOps/pluto"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	Name                 string             `json:"name"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	FullDescription      sarifMessage       `json:"fullDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+	Properties           map[string]string  `json:"properties,omitempty"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID        string             `json:"ruleId"`
+	RuleIndex     int                `json:"ruleIndex"`
+	Level         string             `json:"level"`
+	Message       sarifMessage       `json:"message"`
+	Locations     []sarifLocation    `json:"locations,omitempty"`
+	Suppressions  []sarifSuppression `json:"suppressions,omitempty"`
+	BaselineState string             `json:"baselineState,omitempty"`
+}
+
+type sarifSuppression struct {
+	Kind          string `json:"kind"`
+	Justification string `json:"justification,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// sarifOut builds a SARIF 2.1.0 log from the outputs. There is one rule
+// for each deprecated version that was found, named after the state of
+// the first output that uses it.
+func (instance *Instance) sarifOut() ([]byte, error) {
+	driver := sarifDriver{
+		Name:           "pluto",
+		InformationURI: plutoURI,
+		Rules:          []sarifRule{},
+	}
+	results := []sarifResult{}
+	ruleIndexes := make(map[string]int)
+
+	outputs := append(append([]*Output{}, instance.Outputs...), instance.Baselined...)
+	for i, o := range outputs {
+		id := sarifRuleID(o.APIVersion)
+		index, found := ruleIndexes[id]
+		if !found {
+			index = len(driver.Rules)
+			ruleIndexes[id] = index
+			driver.Rules = append(driver.Rules, newSarifRule(id, o))
+		}
+
+		result := sarifResult{
+			RuleID:    id,
+			RuleIndex: index,
+			Level:     sarifLevel(o),
+			Message:   sarifMessage{Text: sarifResultMessage(o)},
+		}
+		if o.FilePath != "" {
+			location := sarifLocation{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: relativePath(o.FilePath)},
+				},
+			}
+			if o.Line > 0 {
+				location.PhysicalLocation.Region = &sarifRegion{
+					StartLine:   o.Line,
+					StartColumn: o.Column,
+				}
+			}
+			result.Locations = []sarifLocation{location}
+		}
+		if o.Suppressed {
+			result.Suppressions = []sarifSuppression{{
+				Kind:          "inSource",
+				Justification: fmt.Sprintf("%s annotation or %s comment", IgnoreAnnotation, IgnoreComment),
+			}}
+		}
+		if instance.Baseline != nil {
+			result.BaselineState = "new"
+			if i >= len(instance.Outputs) {
+				result.BaselineState = "unchanged"
+			}
+		}
+		results = append(results, result)
+	}
+
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{
+			{
+				Tool:    sarifTool{Driver: driver},
+				Results: results,
+			},
+		},
+	}
+	return json.MarshalIndent(log, "", "  ")
+}
+
+// sarifRuleID is the kind and apiVersion of a version, for example Deployment/extensions/v1beta1
+func sarifRuleID(v *Version) string {
+	return fmt.Sprintf("%s/%s", v.Kind, v.Name)
+}
+
+// newSarifRule returns the rule for the version of an output. The name, short description
+// and level of the rule follow whether the version is too new, removed or deprecated.
+func newSarifRule(id string, o *Output) sarifRule {
+	v := o.APIVersion
+	rule := sarifRule{
+		ID:                   id,
+		Name:                 "DeprecatedAPIVersion",
+		ShortDescription:     sarifMessage{Text: fmt.Sprintf("%s %s is deprecated", v.Kind, v.Name)},
+		DefaultConfiguration: sarifConfiguration{Level: sarifLevel(o)},
+		Properties: map[string]string{
+			"component": v.Component,
+		},
+	}
+
+	switch {
+	case o.TooNew:
+		rule.Name = "UnavailableAPIVersion"
+		rule.ShortDescription = sarifMessage{Text: fmt.Sprintf("%s %s is not available yet", v.Kind, v.Name)}
+	case o.Removed:
+		rule.Name = "RemovedAPIVersion"
+		rule.ShortDescription = sarifMessage{Text: fmt.Sprintf("%s %s is removed", v.Kind, v.Name)}
+	}
+
+	var details []string
+	if v.IntroducedIn != "" {
+		details = append(details, fmt.Sprintf("introduced in %s", v.IntroducedIn))
+	}
+	if v.DeprecatedIn != "" {
+		details = append(details, fmt.Sprintf("deprecated in %s", v.DeprecatedIn))
+	}
+	if v.RemovedIn != "" {
+		details = append(details, fmt.Sprintf("removed in %s", v.RemovedIn))
+	}
+	if v.ReplacementAPI != "" {
+		replacement := fmt.Sprintf("replaced by %s", v.ReplacementAPI)
+		if v.ReplacementAvailableIn != "" {
+			replacement = fmt.Sprintf("%s (available in %s)", replacement, v.ReplacementAvailableIn)
+		}
+		details = append(details, replacement)
+	}
+	rule.FullDescription = sarifMessage{Text: fmt.Sprintf("%s %s is %s.", v.Kind, v.Name, strings.Join(details, ", "))}
+	return rule
+}
+
+// sarifLevel maps an output to a SARIF level. Removed and too new apiVersions are errors and
+// deprecated ones are warnings, unless the replacement is not available yet, in
+// which case there is nothing to do but take note.
+func sarifLevel(o *Output) string {
+	switch {
+	case o.Removed || o.TooNew:
+		return "error"
+	case o.Deprecated && o.ReplacementAvailable:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+func sarifResultMessage(o *Output) string {
+	v := o.APIVersion
+	var status string
+	if o.TooNew {
+		status = fmt.Sprintf("not available until %s", v.IntroducedIn)
+	} else if o.Removed {
+		status = fmt.Sprintf("removed in %s", v.RemovedIn)
+	} else {
+		status = fmt.Sprintf("deprecated in %s", v.DeprecatedIn)
+	}
+	message := fmt.Sprintf("%s %s uses %s, which is %s.", v.Kind, o.Name, v.Name, status)
+	if v.ReplacementAPI != "" {
+		message = fmt.Sprintf("%s Use %s instead.", message, v.ReplacementAPI)
+	}
+	return message
+}
@@ -0,0 +1,79 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// IgnoreAnnotation is the annotation that suppresses findings for a resource when set to true
+	IgnoreAnnotation = "pluto.fairwinds.com/ignore"
+	// IgnoreComment is the comment that suppresses findings for the manifest below it
+	IgnoreComment = "pluto:ignore"
+)
+
+// isSuppressed returns true if the stub has the ignore annotation or comment
+func (stub *Stub) isSuppressed() bool {
+	if stub.IgnoreComment {
+		return true
+	}
+	switch value := stub.Metadata.Annotations[IgnoreAnnotation].(type) {
+	case string:
+		ignore, err := strconv.ParseBool(value)
+		return err == nil && ignore
+	case bool:
+		return value
+	default:
+		return false
+	}
+}
+
+// setIgnoreComment records whether the manifest in node, and any List items,
+// have an ignore comment above them
+func (stub *Stub) setIgnoreComment(node *yaml.Node, lines []string) {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	stub.IgnoreComment = hasIgnoreComment(lines, node.Line)
+	if items := mappingValue(node, "items"); items != nil && items.Kind == yaml.SequenceNode && len(items.Content) == len(stub.Items) {
+		for i := range stub.Items {
+			stub.Items[i].setIgnoreComment(items.Content[i], lines)
+		}
+	}
+}
+
+// hasIgnoreComment looks at the comments and blank lines directly above a line,
+// starting at 1, for an ignore comment. yaml.v3 does not reliably attach comments
+// that are separated from a document by a blank line, so the lines are read directly.
+func hasIgnoreComment(lines []string, line int) bool {
+	for i := line - 2; i >= 0 && i < len(lines); i-- {
+		text := strings.TrimSpace(lines[i])
+		if text == "" {
+			continue
+		}
+		if !strings.HasPrefix(text, "#") {
+			return false
+		}
+		comment := strings.TrimSpace(strings.TrimPrefix(text, "#"))
+		if comment == IgnoreComment || strings.HasPrefix(comment, IgnoreComment+" ") {
+			return true
+		}
+	}
+	return false
+}
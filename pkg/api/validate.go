@@ -0,0 +1,126 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"golang.org/x/mod/semver"
+	"gopkg.in/yaml.v3"
+)
+
+// ValidateVersions checks the data of a versions file and returns an error that lists every
+// problem found. Unknown keys, values that are not valid semver, versions that are removed
+// before they are deprecated, replacements that are available after the version is removed,
+// duplicates and replacements that are deprecated in the same version are all problems.
+// The known versions, like the defaults, are not checked themselves. Versions in data must not
+// duplicate one of them, and they are searched for replacements that are deprecated.
+func ValidateVersions(data []byte, known []Version) error {
+	versionFile := &VersionFile{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	var problems []error
+	err := decoder.Decode(versionFile)
+	if err != nil {
+		var tError *yaml.TypeError
+		switch {
+		case err == io.EOF:
+			return fmt.Errorf("versions file is empty")
+		case errors.As(err, &tError):
+			for _, e := range tError.Errors {
+				problems = append(problems, errors.New(e))
+			}
+		default:
+			return fmt.Errorf("could not unmarshal versions file from data: %s", err.Error())
+		}
+	}
+
+	components := make([]string, 0, len(versionFile.TargetVersions))
+	for component := range versionFile.TargetVersions {
+		components = append(components, component)
+	}
+	sort.Strings(components)
+	for _, component := range components {
+		if targetVersion := versionFile.TargetVersions[component]; !semver.IsValid(targetVersion) {
+			problems = append(problems, fmt.Errorf("target-versions: %s is not valid semver with a leading 'v' - got %s", component, targetVersion))
+		}
+	}
+
+	all := append(append([]Version{}, versionFile.DeprecatedVersions...), known...)
+	for i, v := range versionFile.DeprecatedVersions {
+		for _, problem := range v.validate() {
+			problems = append(problems, fmt.Errorf("deprecated-versions[%d] %s %s: %s", i, v.Kind, v.Name, problem))
+		}
+		for j, other := range versionFile.DeprecatedVersions[:i] {
+			if isDuplicate(v, other) {
+				problems = append(problems, fmt.Errorf("deprecated-versions[%d] %s %s: duplicate of deprecated-versions[%d]", i, v.Kind, v.Name, j))
+			}
+		}
+		if v.isContainedIn(known) {
+			problems = append(problems, fmt.Errorf("deprecated-versions[%d]: duplicate cannot be added to defaults: %s %s", i, v.Kind, v.Name))
+		}
+		if replacement := v.deprecatedReplacement(all); replacement != nil {
+			problems = append(problems, fmt.Errorf("deprecated-versions[%d] %s %s: replacement-api %s is deprecated in %s, at or before deprecated-in %s", i, v.Kind, v.Name, replacement.Name, replacement.DeprecatedIn, v.DeprecatedIn))
+		}
+	}
+	return errors.Join(problems...)
+}
+
+// validate returns the problems with the versions of a single version
+func (v Version) validate() []string {
+	var problems []string
+	fields := []struct {
+		name  string
+		value string
+	}{
+		{"deprecated-in", v.DeprecatedIn},
+		{"removed-in", v.RemovedIn},
+		{"replacement-available-in", v.ReplacementAvailableIn},
+		{"introduced-in", v.IntroducedIn},
+	}
+	for _, field := range fields {
+		if field.value != "" && !semver.IsValid(field.value) {
+			problems = append(problems, fmt.Sprintf("%s is not valid semver with a leading 'v' - got %s", field.name, field.value))
+		}
+	}
+	if semver.IsValid(v.DeprecatedIn) && semver.IsValid(v.RemovedIn) && semver.Compare(v.RemovedIn, v.DeprecatedIn) < 0 {
+		problems = append(problems, fmt.Sprintf("removed-in %s is before deprecated-in %s", v.RemovedIn, v.DeprecatedIn))
+	}
+	if semver.IsValid(v.ReplacementAvailableIn) && semver.IsValid(v.RemovedIn) && semver.Compare(v.ReplacementAvailableIn, v.RemovedIn) > 0 {
+		problems = append(problems, fmt.Sprintf("replacement-available-in %s is after removed-in %s", v.ReplacementAvailableIn, v.RemovedIn))
+	}
+	return problems
+}
+
+// deprecatedReplacement returns the replacement of the version if it is
+// deprecated in the same version as, or before, the version itself
+func (v Version) deprecatedReplacement(versions []Version) *Version {
+	if v.ReplacementAPI == "" || v.ReplacementAPI == v.Name || !semver.IsValid(v.DeprecatedIn) {
+		return nil
+	}
+	for i, replacement := range versions {
+		if replacement.Name != v.ReplacementAPI || replacement.Kind != v.Kind || replacement.Component != v.Component {
+			continue
+		}
+		if semver.IsValid(replacement.DeprecatedIn) && semver.Compare(replacement.DeprecatedIn, v.DeprecatedIn) <= 0 {
+			return &versions[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	plutoversionsfile "github.com/fairwindsops/pluto/v5"
+)
+
+func TestValidateVersions(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			data: `target-versions:
+  custom: v1.0.0
+deprecated-versions:
+- version: someother/v1beta1
+  kind: AnotherCRD
+  deprecated-in: v1.9.0
+  removed-in: v1.16.0
+  replacement-api: someother/v1
+  replacement-available-in: v1.9.0
+  component: custom
+`,
+		},
+		{
+			name: "unknown key",
+			data: `deprecated-versions:
+- version: someother/v1beta1
+  kind: AnotherCRD
+  deprecated_in: v1.9.0
+  component: custom
+`,
+			wantErr: "line 4: field deprecated_in not found in type api.Version",
+		},
+		{
+			name: "invalid semver",
+			data: `target-versions:
+  custom: "1.0"
+deprecated-versions:
+- version: someother/v1beta1
+  kind: AnotherCRD
+  deprecated-in: "1.9"
+  introduced-in: v1.foo
+  component: custom
+`,
+			wantErr: "target-versions: custom is not valid semver with a leading 'v' - got 1.0\n" +
+				"deprecated-versions[0] AnotherCRD someother/v1beta1: deprecated-in is not valid semver with a leading 'v' - got 1.9\n" +
+				"deprecated-versions[0] AnotherCRD someother/v1beta1: introduced-in is not valid semver with a leading 'v' - got v1.foo",
+		},
+		{
+			name: "out of order",
+			data: `deprecated-versions:
+- version: someother/v1beta1
+  kind: AnotherCRD
+  deprecated-in: v1.16.0
+  removed-in: v1.9.0
+  replacement-api: someother/v1
+  replacement-available-in: v1.20.0
+  component: custom
+`,
+			wantErr: "deprecated-versions[0] AnotherCRD someother/v1beta1: removed-in v1.9.0 is before deprecated-in v1.16.0\n" +
+				"deprecated-versions[0] AnotherCRD someother/v1beta1: replacement-available-in v1.20.0 is after removed-in v1.9.0",
+		},
+		{
+			name: "duplicates",
+			data: `deprecated-versions:
+- version: someother/v1beta1
+  kind: AnotherCRD
+  component: custom
+- version: someother/v1beta1
+  kind: AnotherCRD
+  component: custom
+- version: extensions/v1beta1
+  kind: Deployment
+  component: custom
+`,
+			wantErr: "deprecated-versions[1] AnotherCRD someother/v1beta1: duplicate of deprecated-versions[0]\n" +
+				"deprecated-versions[2]: duplicate cannot be added to defaults: Deployment extensions/v1beta1",
+		},
+		{
+			name: "deprecated replacement",
+			data: `deprecated-versions:
+- version: someother/v1beta1
+  kind: AnotherCRD
+  deprecated-in: v1.9.0
+  replacement-api: someother/v1beta2
+  component: custom
+- version: someother/v1beta2
+  kind: AnotherCRD
+  deprecated-in: v1.9.0
+  replacement-api: someother/v1
+  component: custom
+- version: someother/v1
+  kind: AnotherCRD
+  deprecated-in: v1.20.0
+  component: custom
+`,
+			wantErr: "deprecated-versions[0] AnotherCRD someother/v1beta1: replacement-api someother/v1beta2 is deprecated in v1.9.0, at or before deprecated-in v1.9.0",
+		},
+		{
+			name:    "empty",
+			data:    "",
+			wantErr: "versions file is empty",
+		},
+		{
+			name:    "not yaml",
+			data:    "*.",
+			wantErr: "could not unmarshal versions file from data: yaml: did not find expected alphabetic or numeric character",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateVersions([]byte(tt.data), []Version{testVersionDeployment})
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestValidateVersions_defaults(t *testing.T) {
+	// The default versions.yaml must pass the same checks as additional versions files.
+	assert.NoError(t, ValidateVersions(plutoversionsfile.Content(), nil))
+}
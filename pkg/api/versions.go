@@ -34,7 +34,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"os"
+	"strings"
 	"text/tabwriter"
 
 	"golang.org/x/mod/semver"
@@ -48,12 +48,23 @@ type Stub struct {
 	APIVersion string   `json:"apiVersion" yaml:"apiVersion"`
 	Metadata   StubMeta `json:"metadata" yaml:"metadata"`
 	Items      []Stub   `json:"items" yaml:"items"`
+	// Document is the position of the manifest in a multi-document file, starting at 1
+	Document int `json:"-" yaml:"-"`
+	// Line is the line of the apiVersion key in the file
+	Line int `json:"-" yaml:"-"`
+	// Column is the column of the apiVersion key in the file
+	Column int `json:"-" yaml:"-"`
+	// IgnoreComment is true if there is a pluto:ignore comment above the manifest
+	IgnoreComment bool `json:"-" yaml:"-"`
 }
 
 // StubMeta will catch kube resource metadata
 type StubMeta struct {
 	Name      string `json:"name" yaml:"name"`
 	Namespace string `json:"namespace" yaml:"namespace"`
+	// Annotations are decoded leniently, because some tools write values that are
+	// not strings. Only the ignore annotation is read.
+	Annotations map[string]interface{} `json:"annotations" yaml:"annotations"`
 }
 
 // Version is an apiVersion and a flag for deprecation
@@ -74,6 +85,9 @@ type Version struct {
 	ReplacementAvailableIn string `json:"replacement-available-in" yaml:"replacement-available-in"`
 	// Component is the component associated with this version
 	Component string `json:"component" yaml:"component"`
+	// IntroducedIn is the version that the api was added in
+	// An empty string indicates that the api is available in every version
+	IntroducedIn string `json:"introduced-in,omitempty" yaml:"introduced-in,omitempty"`
 }
 
 // VersionFile is a file with a list of deprecated versions
@@ -94,13 +108,58 @@ func (instance *Instance) checkVersion(stub *Stub) *Version {
 			}
 		}
 	}
-	return nil
+	return instance.introducedVersion(stub)
+}
+
+// introducedVersion returns a version for a stub that uses the replacement of a
+// deprecated version, if the replacement is not available in the target version yet.
+// The replacement is introduced in the earliest version it is available in.
+func (instance *Instance) introducedVersion(stub *Stub) *Version {
+	var introduced *Version
+	for _, version := range instance.DeprecatedVersions {
+		if version.ReplacementAPI != stub.APIVersion || version.ReplacementAvailableIn == "" {
+			continue
+		}
+		if version.Kind != "" && version.Kind != stub.Kind {
+			continue
+		}
+		if introduced == nil || semver.Compare(version.ReplacementAvailableIn, introduced.IntroducedIn) < 0 {
+			introduced = &Version{
+				Name:         stub.APIVersion,
+				Kind:         stub.Kind,
+				IntroducedIn: version.ReplacementAvailableIn,
+				Component:    version.Component,
+			}
+		}
+	}
+	if introduced == nil || !introduced.isTooNewIn(instance.TargetVersions) {
+		return nil
+	}
+	return introduced
+}
+
+// reportsEveryResource is true if the output format reports the resources that do not
+// use a known apiVersion too. Those resources are outputs with a version that has only
+// a name and kind, which FilterOutput removes.
+func (instance *Instance) reportsEveryResource() bool {
+	return instance.OutputFormat == "junit"
 }
 
 // IsVersioned returns a version if the file data sent
 // can be unmarshaled into a stub and matches a known
-// version in the VersionList
+// version in the VersionList. The data is not read from a file
+// that the user can open, so the outputs have no position.
 func (instance *Instance) IsVersioned(data []byte) ([]*Output, error) {
+	outputs, err := instance.IsVersionedFile(data)
+	for _, output := range outputs {
+		output.Document, output.Line, output.Column = 0, 0, 0
+	}
+	return outputs, err
+}
+
+// IsVersionedFile is IsVersioned for the contents of a file. The outputs
+// include the document, line and column of each apiVersion in data.
+func (instance *Instance) IsVersionedFile(data []byte) ([]*Output, error) {
 	var outputs []*Output
 	stubs, err := containsStub(data)
 	if err != nil {
@@ -110,10 +169,19 @@ func (instance *Instance) IsVersioned(data []byte) ([]*Output, error) {
 		for _, stub := range stubs {
 			var output Output
 			version := instance.checkVersion(stub)
+			if version == nil && instance.reportsEveryResource() && stub.APIVersion != "" && stub.Kind != "" {
+				// a resource on an apiVersion that is not in the versions list,
+				// which the junit output reports as a passing test case
+				version = &Version{Name: stub.APIVersion, Kind: stub.Kind}
+			}
 			if version != nil {
 				output.Name = stub.Metadata.Name
 				output.Namespace = stub.Metadata.Namespace
 				output.APIVersion = version
+				output.Document = stub.Document
+				output.Line = stub.Line
+				output.Column = stub.Column
+				output.Suppressed = stub.isSuppressed()
 			} else {
 				continue
 			}
@@ -149,22 +217,36 @@ func jsonToStub(data []byte) ([]*Stub, error) {
 	if err != nil {
 		return nil, err
 	}
+	// JSON is valid YAML, so the yaml parser can tell us where things are.
+	// If it can't, the positions are left empty.
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err == nil {
+		stub.setPosition(&node, 1)
+	}
 	expandList(&stubs, stub)
 	return stubs, nil
 }
 
 func yamlToStub(data []byte) ([]*Stub, error) {
 	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	lines := strings.Split(string(data), "\n")
 	var stubs []*Stub
 	var tError *yaml.TypeError
 	var errs []error
+	document := 0
 	for {
-		stub := &Stub{}
-		err := decoder.Decode(stub)
+		var node yaml.Node
+		err := decoder.Decode(&node)
 		if err != nil {
 			if err == io.EOF {
 				break
 			}
+			return stubs, err
+		}
+		document++
+		stub := &Stub{}
+		err = node.Decode(stub)
+		if err != nil {
 			if errors.As(err, &tError) {
 				klog.V(2).Infof("skipping for invalid yaml in manifest: %s", err)
 				errs = append(errs, err)
@@ -172,6 +254,8 @@ func yamlToStub(data []byte) ([]*Stub, error) {
 			}
 			return stubs, err
 		}
+		stub.setPosition(&node, document)
+		stub.setIgnoreComment(&node, lines)
 		expandList(&stubs, stub)
 	}
 	if stubs == nil && len(errs) > 0 {
@@ -180,6 +264,33 @@ func yamlToStub(data []byte) ([]*Stub, error) {
 	return stubs, nil
 }
 
+// setPosition records the document index and the position of the apiVersion
+// key for the stub, and for any items if the stub is a List
+func (stub *Stub) setPosition(node *yaml.Node, document int) {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	stub.Document = document
+	stub.Line, stub.Column = node.Line, node.Column
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		switch key.Value {
+		case "apiVersion":
+			stub.Line, stub.Column = key.Line, key.Column
+		case "items":
+			if value.Kind != yaml.SequenceNode || len(value.Content) != len(stub.Items) {
+				continue
+			}
+			for j := range stub.Items {
+				stub.Items[j].setPosition(value.Content[j], document)
+			}
+		}
+	}
+}
+
 // expandList checks if we have a List manifest.
 // If it is the case, the manifests inside are expanded, otherwise we just return the single manifest
 func expandList(stubs *[]*Stub, currentStub *Stub) {
@@ -194,6 +305,30 @@ func expandList(stubs *[]*Stub, currentStub *Stub) {
 	}
 }
 
+// isTooNewIn returns true if the version is introduced after the applicable targetVersion
+// Will return false if the targetVersion passed is not a valid semver string
+func (v *Version) isTooNewIn(targetVersions map[string]string) bool {
+	for component, targetVersion := range targetVersions {
+		if !semver.IsValid(targetVersion) {
+			klog.V(3).Infof("targetVersion %s for %s is not valid semVer", targetVersion, component)
+			return false
+		}
+	}
+
+	if v.IntroducedIn == "" {
+		return false
+	}
+
+	targetVersion, ok := targetVersions[v.Component]
+	if !ok {
+		klog.V(3).Infof("targetVersion missing for component %s", v.Component)
+		return false
+	}
+
+	comparison := semver.Compare(targetVersion, v.IntroducedIn)
+	return comparison < 0
+}
+
 // IsDeprecatedIn returns true if the version is deprecated in the applicable targetVersion
 // Will return false if the targetVersion passed is not a valid semver string
 func (v *Version) isDeprecatedIn(targetVersions map[string]string) bool {
@@ -266,12 +401,12 @@ func (v *Version) isReplacementAvailableIn(targetVersions map[string]string) boo
 	return comparison >= 0
 }
 
-// PrintVersionList prints out the list of versions
-// in a specific format
-func (instance *Instance) PrintVersionList(outputFormat string) error {
+// PrintVersionList writes the list of versions
+// to out in a specific format
+func (instance *Instance) PrintVersionList(out io.Writer, outputFormat string) error {
 	switch outputFormat {
 	case "normal", "wide":
-		err := instance.printVersionsTabular()
+		err := instance.printVersionsTabular(out)
 		if err != nil {
 			return err
 		}
@@ -284,7 +419,7 @@ func (instance *Instance) PrintVersionList(outputFormat string) error {
 		if err != nil {
 			return err
 		}
-		fmt.Println(string(data))
+		_, _ = fmt.Fprintln(out, string(data))
 	case "yaml":
 		versionFile := VersionFile{
 			DeprecatedVersions: instance.DeprecatedVersions,
@@ -294,17 +429,17 @@ func (instance *Instance) PrintVersionList(outputFormat string) error {
 		if err != nil {
 			return err
 		}
-		fmt.Println(string(data))
+		_, _ = fmt.Fprintln(out, string(data))
 	default:
-		fmt.Println("The output format must be one of (normal|wide|json|yaml)")
+		_, _ = fmt.Fprintln(out, "The output format must be one of (normal|wide|json|yaml)")
 		return fmt.Errorf("The output format must be one of (normal|wide|json|yaml)")
 	}
 	return nil
 }
 
-func (instance *Instance) printVersionsTabular() error {
+func (instance *Instance) printVersionsTabular(out io.Writer) error {
 	w := new(tabwriter.Writer)
-	w.Init(os.Stdout, 0, 15, 2, padChar, 0)
+	w.Init(out, 0, 15, 2, padChar, 0)
 
 	if !instance.NoHeaders {
 		fmt.Fprintln(w, "KIND\t NAME\t DEPRECATED IN\t REMOVED IN\t REPLACEMENT\t REPL AVAIL IN\t COMPONENT\t")
@@ -374,6 +509,18 @@ func CombineAdditionalVersions(additional []Version, defaults []Version) ([]Vers
 	return returnList, nil
 }
 
+// AddDeprecatedVersions adds versions that are not already known, like the versions
+// that the API server reported as deprecated. Versions that are already known are skipped.
+func (instance *Instance) AddDeprecatedVersions(versions []Version) {
+	for _, version := range versions {
+		if version.isContainedIn(instance.DeprecatedVersions) {
+			continue
+		}
+		klog.V(3).Infof("adding version reported by the API server: %v", version)
+		instance.DeprecatedVersions = append(instance.DeprecatedVersions, version)
+	}
+}
+
 func (v Version) isContainedIn(versionList []Version) bool {
 	for _, version := range versionList {
 		if isDuplicate(v, version) {
@@ -30,6 +30,7 @@ package api
 
 import (
 	_ "embed"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -83,7 +84,7 @@ func Test_jsonToStub(t *testing.T) {
 		{
 			name:    "json not stub",
 			data:    []byte("{}"),
-			want:    []*Stub{{}},
+			want:    []*Stub{{Document: 1, Line: 1, Column: 1}},
 			wantErr: false,
 		},
 		{
@@ -95,13 +96,13 @@ func Test_jsonToStub(t *testing.T) {
 		{
 			name:    "json is stub",
 			data:    []byte(`{"kind": "foo", "apiVersion": "bar"}`),
-			want:    []*Stub{{Kind: "foo", APIVersion: "bar"}},
+			want:    []*Stub{{Kind: "foo", APIVersion: "bar", Document: 1, Line: 1, Column: 17}},
 			wantErr: false,
 		},
 		{
 			name:    "json list is multiple stubs",
 			data:    []byte(`{"kind": "List", "apiVersion": "v1", "items": [{"kind": "foo", "apiVersion": "bar"},{"kind": "bar", "apiVersion": "foo"}]}`),
-			want:    []*Stub{{Kind: "foo", APIVersion: "bar"}, {Kind: "bar", APIVersion: "foo"}},
+			want:    []*Stub{{Kind: "foo", APIVersion: "bar", Document: 1, Line: 1, Column: 64}, {Kind: "bar", APIVersion: "foo", Document: 1, Line: 1, Column: 101}},
 			wantErr: false,
 		},
 	}
@@ -129,7 +130,7 @@ func Test_yamlToStub(t *testing.T) {
 		{
 			name:    "yaml not stub",
 			data:    []byte("foo: bar"),
-			want:    []*Stub{{}},
+			want:    []*Stub{{Document: 1, Line: 1, Column: 1}},
 			wantErr: false,
 		},
 		{
@@ -141,13 +142,19 @@ func Test_yamlToStub(t *testing.T) {
 		{
 			name:    "yaml is stub",
 			data:    []byte("kind: foo\napiVersion: bar"),
-			want:    []*Stub{{Kind: "foo", APIVersion: "bar"}},
+			want:    []*Stub{{Kind: "foo", APIVersion: "bar", Document: 1, Line: 2, Column: 1}},
 			wantErr: false,
 		},
 		{
 			name:    "yaml list is multiple stubs",
 			data:    []byte("kind: List\napiVersion: v1\nitems:\n- kind: foo\n  apiVersion: bar\n- kind: bar\n  apiVersion: foo"),
-			want:    []*Stub{{Kind: "foo", APIVersion: "bar"}, {Kind: "bar", APIVersion: "foo"}},
+			want:    []*Stub{{Kind: "foo", APIVersion: "bar", Document: 1, Line: 5, Column: 3}, {Kind: "bar", APIVersion: "foo", Document: 1, Line: 7, Column: 3}},
+			wantErr: false,
+		},
+		{
+			name:    "multiple documents",
+			data:    []byte("kind: foo\napiVersion: bar\n---\n# comment\napiVersion: foo\nkind: bar"),
+			want:    []*Stub{{Kind: "foo", APIVersion: "bar", Document: 1, Line: 2, Column: 1}, {Kind: "bar", APIVersion: "foo", Document: 2, Line: 5, Column: 1}},
 			wantErr: false,
 		},
 	}
@@ -175,7 +182,7 @@ func Test_containsStub(t *testing.T) {
 		{
 			name:    "yaml not stub",
 			data:    []byte("foo: bar"),
-			want:    []*Stub{{}},
+			want:    []*Stub{{Document: 1, Line: 1, Column: 1}},
 			wantErr: false,
 		},
 		{
@@ -187,13 +194,13 @@ func Test_containsStub(t *testing.T) {
 		{
 			name:    "yaml is stub",
 			data:    []byte("kind: foo\napiVersion: bar"),
-			want:    []*Stub{{Kind: "foo", APIVersion: "bar"}},
+			want:    []*Stub{{Kind: "foo", APIVersion: "bar", Document: 1, Line: 2, Column: 1}},
 			wantErr: false,
 		},
 		{
 			name:    "json not stub",
 			data:    []byte("{}"),
-			want:    []*Stub{{}},
+			want:    []*Stub{{Document: 1, Line: 1, Column: 1}},
 			wantErr: false,
 		},
 		{
@@ -211,7 +218,7 @@ func Test_containsStub(t *testing.T) {
 		{
 			name:    "json is stub",
 			data:    []byte(`{"kind": "foo", "apiVersion": "bar"}`),
-			want:    []*Stub{{Kind: "foo", APIVersion: "bar"}},
+			want:    []*Stub{{Kind: "foo", APIVersion: "bar", Document: 1, Line: 1, Column: 17}},
 			wantErr: false,
 		},
 	}
@@ -229,7 +236,7 @@ func Test_containsStub(t *testing.T) {
 	}
 }
 
-func Test_IsVersioned(t *testing.T) {
+func Test_IsVersionedFile(t *testing.T) {
 	tests := []struct {
 		name    string
 		data    []byte
@@ -251,13 +258,61 @@ func Test_IsVersioned(t *testing.T) {
 		{
 			name:    "yaml has version",
 			data:    []byte("kind: Deployment\napiVersion: extensions/v1beta1"),
-			want:    []*Output{{APIVersion: &testVersionDeployment}},
+			want:    []*Output{{APIVersion: &testVersionDeployment, Document: 1, Line: 2, Column: 1}},
 			wantErr: false,
 		},
 		{
 			name:    "yaml list has version",
 			data:    []byte("kind: List\napiVersion: v1\nitems:\n- kind: Deployment\n  apiVersion: extensions/v1beta1"),
-			want:    []*Output{{APIVersion: &testVersionDeployment}},
+			want:    []*Output{{APIVersion: &testVersionDeployment, Document: 1, Line: 5, Column: 3}},
+			wantErr: false,
+		},
+		{
+			name:    "yaml ignore annotation",
+			data:    []byte("kind: Deployment\napiVersion: extensions/v1beta1\nmetadata:\n  annotations:\n    pluto.fairwinds.com/ignore: \"true\""),
+			want:    []*Output{{APIVersion: &testVersionDeployment, Document: 1, Line: 2, Column: 1, Suppressed: true}},
+			wantErr: false,
+		},
+		{
+			name:    "yaml ignore annotation false",
+			data:    []byte("kind: Deployment\napiVersion: extensions/v1beta1\nmetadata:\n  annotations:\n    pluto.fairwinds.com/ignore: \"false\""),
+			want:    []*Output{{APIVersion: &testVersionDeployment, Document: 1, Line: 2, Column: 1}},
+			wantErr: false,
+		},
+		{
+			name:    "yaml ignore comment",
+			data:    []byte("kind: Deployment\napiVersion: apps/v1\n---\n# pluto:ignore this targets old clusters\n\nkind: Deployment\napiVersion: extensions/v1beta1\n---\n# another comment\nkind: Deployment\napiVersion: extensions/v1beta1"),
+			want:    []*Output{{APIVersion: &testVersionDeployment, Document: 2, Line: 7, Column: 1, Suppressed: true}, {APIVersion: &testVersionDeployment, Document: 3, Line: 11, Column: 1}},
+			wantErr: false,
+		},
+		{
+			name:    "yaml list item ignore comment",
+			data:    []byte("kind: List\napiVersion: v1\nitems:\n# pluto:ignore\n- kind: Deployment\n  apiVersion: extensions/v1beta1\n- kind: Deployment\n  apiVersion: extensions/v1beta1"),
+			want:    []*Output{{APIVersion: &testVersionDeployment, Document: 1, Line: 6, Column: 3, Suppressed: true}, {APIVersion: &testVersionDeployment, Document: 1, Line: 8, Column: 3}},
+			wantErr: false,
+		},
+		{
+			name:    "yaml annotation that is not a string",
+			data:    []byte("kind: Deployment\napiVersion: extensions/v1beta1\nmetadata:\n  annotations:\n    example.com/config:\n      nested: value\n    example.com/list:\n    - a\n    pluto.fairwinds.com/ignore: \"true\""),
+			want:    []*Output{{APIVersion: &testVersionDeployment, Document: 1, Line: 2, Column: 1, Suppressed: true}},
+			wantErr: false,
+		},
+		{
+			name:    "yaml ignore annotation unquoted",
+			data:    []byte("kind: Deployment\napiVersion: extensions/v1beta1\nmetadata:\n  annotations:\n    pluto.fairwinds.com/ignore: true"),
+			want:    []*Output{{APIVersion: &testVersionDeployment, Document: 1, Line: 2, Column: 1, Suppressed: true}},
+			wantErr: false,
+		},
+		{
+			name:    "json annotation that is not a string",
+			data:    []byte(`{"kind": "Deployment", "apiVersion": "extensions/v1beta1", "metadata": {"annotations": {"example.com/config": {"nested": ["value"]}}}}`),
+			want:    []*Output{{APIVersion: &testVersionDeployment, Document: 1, Line: 1, Column: 24}},
+			wantErr: false,
+		},
+		{
+			name:    "json ignore annotation",
+			data:    []byte(`{"kind": "Deployment", "apiVersion": "extensions/v1beta1", "metadata": {"annotations": {"pluto.fairwinds.com/ignore": "true"}}}`),
+			want:    []*Output{{APIVersion: &testVersionDeployment, Document: 1, Line: 1, Column: 24, Suppressed: true}},
 			wantErr: false,
 		},
 		{
@@ -281,13 +336,13 @@ func Test_IsVersioned(t *testing.T) {
 		{
 			name:    "json has version",
 			data:    []byte(`{"kind": "Deployment", "apiVersion": "extensions/v1beta1"}`),
-			want:    []*Output{{APIVersion: &testVersionDeployment}},
+			want:    []*Output{{APIVersion: &testVersionDeployment, Document: 1, Line: 1, Column: 24}},
 			wantErr: false,
 		},
 		{
 			name:    "json list has version",
 			data:    []byte(`{"kind": "List", "apiVersion": "v1", "items": [{"kind": "Deployment", "apiVersion": "extensions/v1beta1"}]}`),
-			want:    []*Output{{APIVersion: &testVersionDeployment}},
+			want:    []*Output{{APIVersion: &testVersionDeployment, Document: 1, Line: 1, Column: 71}},
 			wantErr: false,
 		},
 		{
@@ -299,7 +354,7 @@ func Test_IsVersioned(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := mockInstance.IsVersioned(tt.data)
+			got, err := mockInstance.IsVersionedFile(tt.data)
 			if tt.wantErr {
 				assert.Error(t, err)
 				assert.Equal(t, tt.want, got)
@@ -307,6 +362,14 @@ func Test_IsVersioned(t *testing.T) {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.want, got)
 			}
+
+			// without a file, the same outputs have no position
+			got, err = mockInstance.IsVersioned(tt.data)
+			for _, output := range tt.want {
+				output.Document, output.Line, output.Column = 0, 0, 0
+			}
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
 		})
 	}
 }
@@ -369,6 +432,27 @@ func TestVersion_IsDeprecatedIn(t *testing.T) {
 	}
 }
 
+func TestVersion_isTooNewIn(t *testing.T) {
+	tests := []struct {
+		name           string
+		targetVersions map[string]string
+		introducedIn   string
+		want           bool
+	}{
+		{name: "before", targetVersions: map[string]string{"foo": "v1.22.0"}, introducedIn: "v1.23.0", want: true},
+		{name: "equal values", targetVersions: map[string]string{"foo": "v1.23.0"}, introducedIn: "v1.23.0", want: false},
+		{name: "after", targetVersions: map[string]string{"foo": "v1.24.0"}, introducedIn: "v1.23.0", want: false},
+		{name: "blank introducedIn", targetVersions: map[string]string{"foo": "v1.0.0"}, introducedIn: "", want: false},
+		{name: "bad semVer", targetVersions: map[string]string{"foo": "foo"}, introducedIn: "v1.23.0", want: false},
+		{name: "targetVersions not included for component", targetVersions: map[string]string{"bar": "v1.0.0"}, introducedIn: "v1.23.0", want: false},
+	}
+	for _, tt := range tests {
+		v := &Version{IntroducedIn: tt.introducedIn, Component: "foo"}
+		got := v.isTooNewIn(tt.targetVersions)
+		assert.Equal(t, tt.want, got, "test failed: "+tt.name)
+	}
+}
+
 func TestVersion_IsRemovedIn(t *testing.T) {
 
 	tests := []struct {
@@ -491,7 +575,7 @@ func ExampleInstance_printVersionsTabular() {
 			{Kind: "testkind", Name: "testname", DeprecatedIn: "", RemovedIn: "", ReplacementAvailableIn: "", Component: "custom"},
 		},
 	}
-	_ = instance.printVersionsTabular()
+	_ = instance.printVersionsTabular(os.Stdout)
 
 	// Output:
 	// KIND-------- NAME---------------- DEPRECATED IN-- REMOVED IN-- REPLACEMENT-- REPL AVAIL IN-- COMPONENT--
@@ -507,7 +591,7 @@ func ExampleInstance_printVersionsTabular_noHeaders() {
 		},
 		NoHeaders: true,
 	}
-	_ = instance.printVersionsTabular()
+	_ = instance.printVersionsTabular(os.Stdout)
 
 	// Output:
 	// Deployment-- extensions/v1beta1-- v1.9.0-- v1.16.0-- apps/v1-- v1.10.0-- k8s-----
@@ -518,7 +602,7 @@ func ExampleInstance_PrintVersionList_json() {
 	instance := Instance{
 		DeprecatedVersions: []Version{testVersionDeployment},
 	}
-	_ = instance.PrintVersionList("json")
+	_ = instance.PrintVersionList(os.Stdout, "json")
 
 	// Output:
 	// {"deprecated-versions":[{"version":"extensions/v1beta1","kind":"Deployment","deprecated-in":"v1.9.0","removed-in":"v1.16.0","replacement-api":"apps/v1","replacement-available-in":"v1.10.0","component":"k8s"}]}
@@ -528,7 +612,7 @@ func ExampleInstance_PrintVersionList_yaml() {
 	instance := Instance{
 		DeprecatedVersions: []Version{testVersionDeployment},
 	}
-	_ = instance.PrintVersionList("yaml")
+	_ = instance.PrintVersionList(os.Stdout, "yaml")
 
 	// Output:
 	// deprecated-versions:
@@ -545,7 +629,7 @@ func ExampleInstance_PrintVersionList_normal() {
 	instance := Instance{
 		DeprecatedVersions: []Version{testVersionDeployment},
 	}
-	_ = instance.PrintVersionList("normal")
+	_ = instance.PrintVersionList(os.Stdout, "normal")
 
 	// Output:
 	// KIND-------- NAME---------------- DEPRECATED IN-- REMOVED IN-- REPLACEMENT-- REPL AVAIL IN-- COMPONENT--
@@ -556,7 +640,7 @@ func ExampleInstance_PrintVersionList_wide() {
 	instance := Instance{
 		DeprecatedVersions: []Version{testVersionDeployment},
 	}
-	_ = instance.PrintVersionList("wide")
+	_ = instance.PrintVersionList(os.Stdout, "wide")
 
 	// Output:
 	// KIND-------- NAME---------------- DEPRECATED IN-- REMOVED IN-- REPLACEMENT-- REPL AVAIL IN-- COMPONENT--
@@ -567,7 +651,7 @@ func ExampleInstance_PrintVersionList_badformat() {
 	instance := Instance{
 		DeprecatedVersions: []Version{testVersionDeployment},
 	}
-	_ = instance.PrintVersionList("foo")
+	_ = instance.PrintVersionList(os.Stdout, "foo")
 
 	// Output:
 	// The output format must be one of (normal|wide|json|yaml)
@@ -760,6 +844,34 @@ func TestInstance_checkVersion(t *testing.T) {
 				Component: "cert-manager",
 			},
 		},
+		{
+			name: "replacement too new",
+			instance: &Instance{
+				TargetVersions: map[string]string{"k8s": "v1.22.0"},
+				DeprecatedVersions: []Version{
+					{Kind: "HorizontalPodAutoscaler", Name: "autoscaling/v2beta1", ReplacementAPI: "autoscaling/v2", ReplacementAvailableIn: "v1.23.0", Component: "k8s"},
+					{Kind: "HorizontalPodAutoscaler", Name: "autoscaling/v2beta2", ReplacementAPI: "autoscaling/v2", ReplacementAvailableIn: "v1.24.0", Component: "k8s"},
+				},
+			},
+			stub: &Stub{Kind: "HorizontalPodAutoscaler", APIVersion: "autoscaling/v2"},
+			want: &Version{
+				Name:         "autoscaling/v2",
+				Kind:         "HorizontalPodAutoscaler",
+				IntroducedIn: "v1.23.0",
+				Component:    "k8s",
+			},
+		},
+		{
+			name: "replacement available",
+			instance: &Instance{
+				TargetVersions: map[string]string{"k8s": "v1.23.0"},
+				DeprecatedVersions: []Version{
+					{Kind: "HorizontalPodAutoscaler", Name: "autoscaling/v2beta1", ReplacementAPI: "autoscaling/v2", ReplacementAvailableIn: "v1.23.0", Component: "k8s"},
+				},
+			},
+			stub: &Stub{Kind: "HorizontalPodAutoscaler", APIVersion: "autoscaling/v2"},
+			want: nil,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -768,3 +880,16 @@ func TestInstance_checkVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestInstance_AddDeprecatedVersions(t *testing.T) {
+	instance := &Instance{
+		DeprecatedVersions: []Version{testVersionDeployment},
+	}
+	widget := Version{Name: "example.com/v1alpha1", Kind: "Widget", DeprecatedIn: "v1.27.0", Component: "k8s"}
+	instance.AddDeprecatedVersions([]Version{
+		{Name: testVersionDeployment.Name, Kind: testVersionDeployment.Kind, DeprecatedIn: "v1.0.0", Component: "k8s"},
+		widget,
+		widget,
+	})
+	assert.Equal(t, []Version{testVersionDeployment, widget}, instance.DeprecatedVersions)
+}
@@ -0,0 +1,212 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/fairwindsops/pluto/v5/pkg/api"
+)
+
+// AuditLog reads a Kubernetes audit log and finds the callers of deprecated apiVersions
+type AuditLog struct {
+	// Path is the audit log file, or - for stdin
+	Path     string
+	Instance *api.Instance
+}
+
+// event holds the fields of an audit event that are needed to find deprecated apiVersions
+type event struct {
+	Stage                    string     `json:"stage"`
+	RequestReceivedTimestamp time.Time  `json:"requestReceivedTimestamp"`
+	StageTimestamp           time.Time  `json:"stageTimestamp"`
+	User                     eventUser  `json:"user"`
+	UserAgent                string     `json:"userAgent"`
+	ObjectRef                *objectRef `json:"objectRef"`
+}
+
+type eventUser struct {
+	Username string `json:"username"`
+}
+
+type objectRef struct {
+	Resource   string `json:"resource"`
+	Namespace  string `json:"namespace"`
+	APIGroup   string `json:"apiGroup"`
+	APIVersion string `json:"apiVersion"`
+}
+
+// caller is a user and user agent that called an apiVersion in a namespace
+type caller struct {
+	apiVersion string
+	kind       string
+	namespace  string
+	username   string
+	userAgent  string
+}
+
+// NewAuditLog returns a new struct with config portions complete.
+func NewAuditLog(path string, instance *api.Instance) *AuditLog {
+	return &AuditLog{
+		Path:     path,
+		Instance: instance,
+	}
+}
+
+// FindVersions streams the audit events in the log and adds an output for every user
+// and user agent that called a known apiVersion in a namespace, with the number of calls and when
+// the last call was made. Only the final stage of each request is counted.
+func (a *AuditLog) FindVersions() error {
+	var r io.Reader
+	if a.Path == "-" {
+		r = os.Stdin
+	} else {
+		f, err := os.Open(a.Path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	return a.findVersions(r)
+}
+
+func (a *AuditLog) findVersions(r io.Reader) error {
+	kinds := a.kindsByResource()
+	// versions caches the version lookup for each apiVersion and kind
+	versions := make(map[string]*api.Version)
+	outputs := make(map[caller]*api.Output)
+	lastSeen := make(map[caller]time.Time)
+	var order []caller
+
+	decoder := json.NewDecoder(r)
+	for count := 1; ; count++ {
+		var e event
+		err := decoder.Decode(&e)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return fmt.Errorf("error reading audit event %d: %w", count, err)
+		}
+		if e.ObjectRef == nil || e.ObjectRef.APIVersion == "" || e.ObjectRef.Resource == "" {
+			continue
+		}
+		if e.Stage == "RequestReceived" || e.Stage == "ResponseStarted" {
+			continue
+		}
+
+		apiVersion := e.ObjectRef.APIVersion
+		if e.ObjectRef.APIGroup != "" {
+			apiVersion = e.ObjectRef.APIGroup + "/" + e.ObjectRef.APIVersion
+		}
+		kind, found := kinds[e.ObjectRef.Resource]
+		if !found {
+			// versions with an empty kind deprecate every resource in the apiVersion
+			kind = e.ObjectRef.Resource
+		}
+
+		key := apiVersion + " " + kind
+		version, cached := versions[key]
+		if !cached {
+			version, err = a.checkVersion(apiVersion, kind)
+			if err != nil {
+				return err
+			}
+			versions[key] = version
+		}
+		if version == nil {
+			continue
+		}
+
+		c := caller{apiVersion: apiVersion, kind: kind, namespace: e.ObjectRef.Namespace, username: e.User.Username, userAgent: e.UserAgent}
+		output, found := outputs[c]
+		if !found {
+			output = &api.Output{
+				Name:       e.User.Username,
+				Namespace:  e.ObjectRef.Namespace,
+				APIVersion: version,
+				User:       e.User.Username,
+				UserAgent:  e.UserAgent,
+			}
+			outputs[c] = output
+			order = append(order, c)
+		}
+		output.Count++
+
+		timestamp := e.StageTimestamp
+		if timestamp.IsZero() {
+			timestamp = e.RequestReceivedTimestamp
+		}
+		if timestamp.After(lastSeen[c]) {
+			lastSeen[c] = timestamp
+			output.LastSeen = timestamp.UTC().Format(time.RFC3339)
+		}
+	}
+
+	for _, c := range order {
+		a.Instance.Outputs = append(a.Instance.Outputs, outputs[c])
+	}
+	return nil
+}
+
+// checkVersion returns the known version for an apiVersion and kind, or nil
+func (a *AuditLog) checkVersion(apiVersion string, kind string) (*api.Version, error) {
+	data, err := json.Marshal(map[string]string{"apiVersion": apiVersion, "kind": kind})
+	if err != nil {
+		return nil, err
+	}
+	outputs, err := a.Instance.IsVersioned(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(outputs) == 0 {
+		return nil, nil
+	}
+	klog.V(2).Infof("found audit events for %s %s", apiVersion, kind)
+	return outputs[0].APIVersion, nil
+}
+
+// kindsByResource maps the resource name that the API uses for each known kind to the kind
+func (a *AuditLog) kindsByResource() map[string]string {
+	kinds := make(map[string]string)
+	for _, v := range a.Instance.DeprecatedVersions {
+		if v.Kind != "" {
+			kinds[resourceName(v.Kind)] = v.Kind
+		}
+	}
+	return kinds
+}
+
+// resourceName returns the lowercase plural name of a kind, like ingresses for Ingress
+func resourceName(kind string) string {
+	name := strings.ToLower(kind)
+	switch {
+	case strings.HasSuffix(name, "s"), strings.HasSuffix(name, "x"), strings.HasSuffix(name, "z"),
+		strings.HasSuffix(name, "ch"), strings.HasSuffix(name, "sh"):
+		return name + "es"
+	case strings.HasSuffix(name, "y") && len(name) > 1 && !strings.ContainsAny(name[len(name)-2:len(name)-1], "aeiou"):
+		return name[:len(name)-1] + "ies"
+	default:
+		return name + "s"
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/fairwindsops/pluto/v5/pkg/api"
+)
+
+var testIngress = api.Version{
+	Name:           "extensions/v1beta1",
+	Kind:           "Ingress",
+	DeprecatedIn:   "v1.14.0",
+	RemovedIn:      "v1.22.0",
+	ReplacementAPI: "networking.k8s.io/v1",
+	Component:      "k8s",
+}
+
+var testDeployment = api.Version{
+	Name:           "extensions/v1beta1",
+	Kind:           "Deployment",
+	DeprecatedIn:   "v1.9.0",
+	RemovedIn:      "v1.16.0",
+	ReplacementAPI: "apps/v1",
+	Component:      "k8s",
+}
+
+func newMockAuditLog(path string) *AuditLog {
+	return NewAuditLog(path, &api.Instance{
+		TargetVersions: map[string]string{
+			"k8s": "v1.16.0",
+		},
+		DeprecatedVersions: []api.Version{testIngress, testDeployment},
+	})
+}
+
+func TestAuditLog_FindVersions(t *testing.T) {
+	a := newMockAuditLog("testdata/audit.log")
+	err := a.FindVersions()
+	assert.NoError(t, err)
+	assert.Equal(t, []*api.Output{
+		{
+			Name:       "system:serviceaccount:ingress:controller",
+			APIVersion: &testIngress,
+			User:       "system:serviceaccount:ingress:controller",
+			UserAgent:  "controller/v1.0",
+			Count:      1,
+			LastSeen:   "2022-05-01T10:00:00Z",
+		},
+		{
+			Name:       "admin",
+			Namespace:  "default",
+			APIVersion: &testDeployment,
+			User:       "admin",
+			UserAgent:  "kubectl/v1.15.0",
+			Count:      1,
+			LastSeen:   "2022-05-01T11:00:00Z",
+		},
+		{
+			Name:       "system:serviceaccount:ingress:controller",
+			Namespace:  "default",
+			APIVersion: &testIngress,
+			User:       "system:serviceaccount:ingress:controller",
+			UserAgent:  "controller/v1.0",
+			Count:      1,
+			LastSeen:   "2022-05-01T12:00:00Z",
+		},
+	}, a.Instance.Outputs)
+}
+
+func TestAuditLog_FindVersions_errors(t *testing.T) {
+	a := newMockAuditLog("testdata/missing.log")
+	assert.Error(t, a.FindVersions())
+
+	a = newMockAuditLog("")
+	err := a.findVersions(strings.NewReader(`{"stage": "ResponseComplete"}` + "\nnot json"))
+	assert.EqualError(t, err, "error reading audit event 2: invalid character 'o' in literal null (expecting 'u')")
+}
+
+func Test_resourceName(t *testing.T) {
+	tests := map[string]string{
+		"Ingress":           "ingresses",
+		"Deployment":        "deployments",
+		"PodSecurityPolicy": "podsecuritypolicies",
+		"Gateway":           "gateways",
+		"Endpoints":         "endpointses",
+		"CronJob":           "cronjobs",
+	}
+	for kind, want := range tests {
+		assert.Equal(t, want, resourceName(kind), kind)
+	}
+}
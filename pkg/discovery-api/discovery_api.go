@@ -34,6 +34,7 @@ import (
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
@@ -51,10 +52,12 @@ type DiscoveryClient struct {
 	DiscoveryClient discovery.DiscoveryInterface
 	Instance        *api.Instance
 	namespace       string
+	warnings        *kube.WarningCollector
 }
 
-// NewDiscoveryClient returns a new struct with config portions complete.
-func NewDiscoveryClient(namespace string, kubeContext string, instance *api.Instance, kubeConfigPath string) (*DiscoveryClient, error) {
+// NewDiscoveryClient returns a new struct with config portions complete. Every request
+// to the cluster, including discovery requests, is cancelled when ctx is done.
+func NewDiscoveryClient(ctx context.Context, namespace string, kubeContext string, instance *api.Instance, kubeConfigPath string) (*DiscoveryClient, error) {
 	cl := &DiscoveryClient{
 		Instance: instance,
 	}
@@ -64,6 +67,9 @@ func NewDiscoveryClient(namespace string, kubeContext string, instance *api.Inst
 	if err != nil {
 		return nil, err
 	}
+	kube.BindContext(ctx, cl.restConfig)
+
+	cl.warnings = kube.Warnings(cl.restConfig)
 
 	if cl.DiscoveryClient, err = discovery.NewDiscoveryClientForConfig(cl.restConfig); err != nil {
 		return nil, err
@@ -78,9 +84,13 @@ func NewDiscoveryClient(namespace string, kubeContext string, instance *api.Inst
 	return cl, nil
 }
 
-// GetApiResources discovers the api-resources for a cluster
-func (cl *DiscoveryClient) GetApiResources() error {
+// GetApiResources discovers the api-resources for a cluster. If ctx is done, the resources
+// found so far are kept in the instance and the error of ctx is returned.
+func (cl *DiscoveryClient) GetApiResources(ctx context.Context) error {
 	resourcelist, err := cl.DiscoveryClient.ServerPreferredResources()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
 	if err != nil {
 		if apierrors.IsNotFound(err) {
 			return err
@@ -91,6 +101,10 @@ func (cl *DiscoveryClient) GetApiResources() error {
 		}
 	}
 
+	if err := cl.addCRDVersions(ctx, resourcelist); err != nil {
+		return err
+	}
+
 	gvrs := []schema.GroupVersionResource{}
 	for _, rl := range resourcelist {
 		for i := range rl.APIResources {
@@ -106,17 +120,24 @@ func (cl *DiscoveryClient) GetApiResources() error {
 
 	var results []map[string]any
 	for _, g := range gvrs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		nri := cl.ClientSet.Resource(g)
 		var ri dynamic.ResourceInterface = nri
 		if cl.namespace != "" {
 			ri = nri.Namespace(cl.namespace)
 		}
 		klog.V(2).Infof("Retrieving : %s.%s.%s", g.Resource, g.Version, g.Group)
-		rs, err := ri.List(context.TODO(), metav1.ListOptions{})
+		rs, err := ri.List(ctx, metav1.ListOptions{})
 		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
 			klog.V(2).Info("Failed to retrieve: ", g, err)
 			continue
 		}
+		cl.addDeprecationWarnings()
 
 		if len(rs.Items) == 0 {
 			klog.V(2).Infof("No annotations for ResourceVer %s", rs.GetAPIVersion())
@@ -137,29 +158,11 @@ func (cl *DiscoveryClient) GetApiResources() error {
 
 		} else {
 			for _, r := range rs.Items {
-				if jsonManifest, ok := r.GetAnnotations()["kubectl.kubernetes.io/last-applied-configuration"]; ok {
-					var manifest map[string]any
-
-					err := json.Unmarshal([]byte(jsonManifest), &manifest)
-					if err != nil {
-						klog.Errorf("failed to parse 'last-applied-configuration' annotation of resource %s/%s: %s", r.GetNamespace(), r.GetName(), err.Error())
-						continue
-					}
-					if r.Object["kind"] != manifest["kind"] {
-						klog.V(2).Infof("Object Kind %s does not match last-applied-configuration-kind %s. Skipping", r.Object["kind"], manifest["kind"])
-						continue
-					}
-					data, err := json.Marshal(manifest)
-					if err != nil {
-						klog.Error("Failed to marshal data ", err.Error())
-						return err
-					}
-					output, err := cl.Instance.IsVersioned(data)
-					if err != nil {
-						return err
-					}
-					cl.Instance.Outputs = append(cl.Instance.Outputs, output...)
+				output, err := cl.checkResource(r)
+				if err != nil {
+					return err
 				}
+				cl.Instance.Outputs = append(cl.Instance.Outputs, output...)
 			}
 		}
 
@@ -168,3 +171,166 @@ func (cl *DiscoveryClient) GetApiResources() error {
 	klog.V(6).Infof("Result from resources: %d", len(results))
 	return nil
 }
+
+// crdResource is the resource for CustomResourceDefinitions
+var crdResource = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+
+// GetCRDs returns the CustomResourceDefinitions in the cluster
+func (cl *DiscoveryClient) GetCRDs(ctx context.Context) ([]api.CustomResourceDefinition, error) {
+	klog.V(2).Infof("Retrieving : %s.%s.%s", crdResource.Resource, crdResource.Version, crdResource.Group)
+	crds, err := cl.ClientSet.Resource(crdResource).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(crds.UnstructuredContent())
+	if err != nil {
+		klog.Error("Failed to marshal data ", err.Error())
+		return nil, err
+	}
+	return api.ReadCRDs(data)
+}
+
+// addCRDVersions adds the deprecated versions that are served by the CustomResourceDefinitions
+// in the cluster, so custom resources are checked against them. They are deprecated in the
+// k8s target version, like the CustomResourceDefinitions that are found in files.
+func (cl *DiscoveryClient) addCRDVersions(ctx context.Context, resourcelist []*metav1.APIResourceList) error {
+	if !servesResource(resourcelist, crdResource) {
+		return nil
+	}
+	crds, err := cl.GetCRDs(ctx)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	if err != nil {
+		klog.V(2).Info("Failed to retrieve: ", crdResource, err)
+		return nil
+	}
+	for _, crd := range crds {
+		cl.Instance.AddDeprecatedVersions(crd.DeprecatedVersions(cl.Instance.TargetVersions["k8s"]))
+	}
+	return nil
+}
+
+// servesResource returns true if the resource is in the list of resources from discovery
+func servesResource(resourcelist []*metav1.APIResourceList, g schema.GroupVersionResource) bool {
+	for _, rl := range resourcelist {
+		if rl.GroupVersion != g.GroupVersion().String() {
+			continue
+		}
+		for _, r := range rl.APIResources {
+			if r.Name == g.Resource {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// addDeprecationWarnings adds the apiVersions that the API server has reported as deprecated,
+// so they are found even if they are not in the versions file
+func (cl *DiscoveryClient) addDeprecationWarnings() {
+	cl.Instance.AddDeprecatedVersions(cl.warnings.DeprecatedVersions(cl.Instance.TargetVersions["k8s"]))
+}
+
+// checkResource returns the outputs for a single resource in the cluster. The
+// last-applied-configuration annotation and the apiVersion that each field manager
+// used are checked, and each output has the field manager that last wrote the
+// resource with that apiVersion.
+func (cl *DiscoveryClient) checkResource(r unstructured.Unstructured) ([]*api.Output, error) {
+	outputs, err := cl.checkLastApplied(r)
+	if err != nil {
+		return nil, err
+	}
+	managed, err := cl.checkManagedFields(r)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range managed {
+		if output := findOutput(outputs, m.APIVersion); output != nil {
+			output.Manager = m.Manager
+			continue
+		}
+		outputs = append(outputs, m)
+	}
+	return outputs, nil
+}
+
+// checkLastApplied returns the outputs for the last-applied-configuration annotation of a resource
+func (cl *DiscoveryClient) checkLastApplied(r unstructured.Unstructured) ([]*api.Output, error) {
+	jsonManifest, ok := r.GetAnnotations()["kubectl.kubernetes.io/last-applied-configuration"]
+	if !ok {
+		return nil, nil
+	}
+	var manifest map[string]any
+	err := json.Unmarshal([]byte(jsonManifest), &manifest)
+	if err != nil {
+		klog.Errorf("failed to parse 'last-applied-configuration' annotation of resource %s/%s: %s", r.GetNamespace(), r.GetName(), err.Error())
+		return nil, nil
+	}
+	if r.Object["kind"] != manifest["kind"] {
+		klog.V(2).Infof("Object Kind %s does not match last-applied-configuration-kind %s. Skipping", r.Object["kind"], manifest["kind"])
+		return nil, nil
+	}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		klog.Error("Failed to marshal data ", err.Error())
+		return nil, err
+	}
+	return cl.Instance.IsVersioned(data)
+}
+
+// checkManagedFields returns an output for each deprecated apiVersion in the managedFields
+// of a resource, with the field manager that most recently wrote the resource with it
+func (cl *DiscoveryClient) checkManagedFields(r unstructured.Unstructured) ([]*api.Output, error) {
+	var apiVersions []string
+	latest := map[string]metav1.ManagedFieldsEntry{}
+	for _, entry := range r.GetManagedFields() {
+		if entry.APIVersion == "" {
+			continue
+		}
+		previous, ok := latest[entry.APIVersion]
+		if !ok {
+			apiVersions = append(apiVersions, entry.APIVersion)
+		} else if entry.Time == nil || (previous.Time != nil && !previous.Time.Before(entry.Time)) {
+			continue
+		}
+		latest[entry.APIVersion] = entry
+	}
+
+	var outputs []*api.Output
+	for _, apiVersion := range apiVersions {
+		manifest := map[string]any{
+			"apiVersion": apiVersion,
+			"kind":       r.GetKind(),
+			"metadata": map[string]any{
+				"name":        r.GetName(),
+				"namespace":   r.GetNamespace(),
+				"annotations": r.GetAnnotations(),
+			},
+		}
+		data, err := json.Marshal(manifest)
+		if err != nil {
+			klog.Error("Failed to marshal data ", err.Error())
+			return nil, err
+		}
+		output, err := cl.Instance.IsVersioned(data)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range output {
+			o.Manager = latest[apiVersion].Manager
+		}
+		outputs = append(outputs, output...)
+	}
+	return outputs, nil
+}
+
+// findOutput returns the output with the same apiVersion and kind as version
+func findOutput(outputs []*api.Output, version *api.Version) *api.Output {
+	for _, output := range outputs {
+		if output.APIVersion.Name == version.Name && output.APIVersion.Kind == version.Kind {
+			return output
+		}
+	}
+	return nil
+}
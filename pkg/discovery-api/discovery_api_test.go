@@ -29,11 +29,22 @@
 package discoveryapi
 
 import (
+	"context"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
 	discoveryFake "k8s.io/client-go/discovery/fake"
 	"k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/fairwindsops/pluto/v5/pkg/api"
+	"github.com/fairwindsops/pluto/v5/pkg/kube"
 )
 
 func TestNewDiscoveryAPIClientValidEmpty(t *testing.T) {
@@ -46,9 +57,251 @@ func TestNewDiscoveryAPIClientValidEmpty(t *testing.T) {
 		DiscoveryClient: &discoveryClient,
 	}
 
-	err := testOpts.GetApiResources()
+	err := testOpts.GetApiResources(context.Background())
 	if err != nil {
 		t.Errorf("Unable to fetch API Resources")
 	}
 
 }
+
+var testVersionIngress = api.Version{
+	Name:           "extensions/v1beta1",
+	Kind:           "Ingress",
+	DeprecatedIn:   "v1.14.0",
+	RemovedIn:      "v1.22.0",
+	ReplacementAPI: "networking.k8s.io/v1",
+	Component:      "k8s",
+}
+
+func newTestResource(annotations map[string]string, managedFields ...metav1.ManagedFieldsEntry) unstructured.Unstructured {
+	r := unstructured.Unstructured{}
+	r.SetAPIVersion("networking.k8s.io/v1")
+	r.SetKind("Ingress")
+	r.SetName("web")
+	r.SetNamespace("default")
+	r.SetAnnotations(annotations)
+	r.SetManagedFields(managedFields)
+	return r
+}
+
+func managedFieldsEntry(manager string, apiVersion string, hour int) metav1.ManagedFieldsEntry {
+	t := metav1.NewTime(time.Date(2022, 5, 1, hour, 0, 0, 0, time.UTC))
+	return metav1.ManagedFieldsEntry{
+		Manager:    manager,
+		Operation:  metav1.ManagedFieldsOperationUpdate,
+		APIVersion: apiVersion,
+		Time:       &t,
+	}
+}
+
+func TestDiscoveryClient_checkResource(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource unstructured.Unstructured
+		want     []*api.Output
+	}{
+		{
+			name:     "no managed fields or annotation",
+			resource: newTestResource(nil),
+		},
+		{
+			name: "managed fields",
+			resource: newTestResource(nil,
+				managedFieldsEntry("helm", "extensions/v1beta1", 1),
+				managedFieldsEntry("ingress-controller", "networking.k8s.io/v1", 3),
+				managedFieldsEntry("old-controller", "extensions/v1beta1", 2),
+			),
+			want: []*api.Output{
+				{Name: "web", Namespace: "default", APIVersion: &testVersionIngress, Manager: "old-controller"},
+			},
+		},
+		{
+			name: "last applied configuration",
+			resource: newTestResource(map[string]string{
+				"kubectl.kubernetes.io/last-applied-configuration": `{"apiVersion":"extensions/v1beta1","kind":"Ingress","metadata":{"name":"web","namespace":"default"}}`,
+			},
+				managedFieldsEntry("kubectl-client-side-apply", "extensions/v1beta1", 1),
+			),
+			want: []*api.Output{
+				{Name: "web", Namespace: "default", APIVersion: &testVersionIngress, Manager: "kubectl-client-side-apply"},
+			},
+		},
+		{
+			name: "last applied configuration with a different manager",
+			resource: newTestResource(map[string]string{
+				"kubectl.kubernetes.io/last-applied-configuration": `{"apiVersion":"extensions/v1beta1","kind":"Ingress","metadata":{"name":"web"}}`,
+			},
+				managedFieldsEntry("kubectl-client-side-apply", "networking.k8s.io/v1", 1),
+			),
+			want: []*api.Output{
+				{Name: "web", APIVersion: &testVersionIngress},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl := DiscoveryClient{
+				Instance: &api.Instance{
+					TargetVersions:     map[string]string{"k8s": "v1.16.0"},
+					DeprecatedVersions: []api.Version{testVersionIngress},
+				},
+			}
+			got, err := cl.checkResource(tt.resource)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDiscoveryClient_addCRDVersions(t *testing.T) {
+	crd := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata":   map[string]any{"name": "widgets.example.com"},
+		"spec": map[string]any{
+			"group": "example.com",
+			"names": map[string]any{"kind": "Widget"},
+			"versions": []any{
+				map[string]any{"name": "v1alpha1", "served": true, "storage": false, "deprecated": true},
+				map[string]any{"name": "v1", "served": true, "storage": true},
+			},
+		},
+	}}
+	gvr := schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+
+	tests := []struct {
+		name          string
+		targetVersion string
+	}{
+		{
+			name:          "target newer than the server",
+			targetVersion: "v1.28.0",
+		},
+		{
+			name:          "target older than the server",
+			targetVersion: "v1.25.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientset := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+				gvr: "CustomResourceDefinitionList",
+			}, crd)
+			discoveryClient := &discoveryFake.FakeDiscovery{
+				Fake:               &k8stesting.Fake{},
+				FakedServerVersion: &version.Info{GitVersion: "v1.27.3"},
+			}
+			cl := DiscoveryClient{
+				ClientSet:       clientset,
+				DiscoveryClient: discoveryClient,
+				Instance: &api.Instance{
+					TargetVersions: map[string]string{"k8s": tt.targetVersion},
+				},
+			}
+			err := cl.addCRDVersions(context.Background(), []*metav1.APIResourceList{
+				{
+					GroupVersion: "apiextensions.k8s.io/v1",
+					APIResources: []metav1.APIResource{{Name: "customresourcedefinitions"}},
+				},
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, []api.Version{
+				{
+					Name:                   "example.com/v1alpha1",
+					Kind:                   "Widget",
+					DeprecatedIn:           tt.targetVersion,
+					ReplacementAPI:         "example.com/v1",
+					ReplacementAvailableIn: tt.targetVersion,
+					Component:              "k8s",
+				},
+			}, cl.Instance.DeprecatedVersions)
+
+			// resources that use the version are reported as deprecated at the target
+			output := &api.Output{APIVersion: &cl.Instance.DeprecatedVersions[0]}
+			cl.Instance.SetOutputStatus(output)
+			assert.True(t, output.Deprecated)
+			assert.True(t, output.ReplacementAvailable)
+		})
+	}
+}
+
+func TestDiscoveryClient_addDeprecationWarnings(t *testing.T) {
+	cl := DiscoveryClient{
+		DiscoveryClient: &discoveryFake.FakeDiscovery{
+			Fake:               &k8stesting.Fake{},
+			FakedServerVersion: &version.Info{GitVersion: "v1.27.3"},
+		},
+		Instance: &api.Instance{
+			TargetVersions: map[string]string{"k8s": "v1.25.0"},
+		},
+		warnings: &kube.WarningCollector{},
+	}
+	cl.warnings.HandleWarningHeader(299, "", "example.com/v1alpha1 Widget is deprecated; use example.com/v1 Widget")
+	cl.addDeprecationWarnings()
+	assert.Equal(t, []api.Version{
+		{
+			Name:           "example.com/v1alpha1",
+			Kind:           "Widget",
+			DeprecatedIn:   "v1.25.0",
+			ReplacementAPI: "example.com/v1",
+			Component:      "k8s",
+		},
+	}, cl.Instance.DeprecatedVersions)
+
+	// the target is older than the server, and resources that use the version are still reported
+	output := &api.Output{APIVersion: &cl.Instance.DeprecatedVersions[0]}
+	cl.Instance.SetOutputStatus(output)
+	assert.True(t, output.Deprecated)
+}
+
+// preferredResourcesDiscovery is a fake discovery client that serves resources
+type preferredResourcesDiscovery struct {
+	*discoveryFake.FakeDiscovery
+	resources []*metav1.APIResourceList
+}
+
+func (d preferredResourcesDiscovery) ServerPreferredResources() ([]*metav1.APIResourceList, error) {
+	return d.resources, nil
+}
+
+func TestDiscoveryClient_GetApiResourcesCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ingresses := schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	clientset := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		ingresses:   "IngressList",
+		deployments: "DeploymentList",
+	})
+	var listed []string
+	clientset.PrependReactor("list", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		listed = append(listed, action.GetResource().Resource)
+		// the scan is cancelled while the first resource is listed
+		cancel()
+		return true, &unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+			newTestResource(nil, managedFieldsEntry("old-controller", "extensions/v1beta1", 1)),
+		}}, nil
+	})
+
+	cl := DiscoveryClient{
+		ClientSet: clientset,
+		DiscoveryClient: preferredResourcesDiscovery{
+			FakeDiscovery: &discoveryFake.FakeDiscovery{Fake: &k8stesting.Fake{}},
+			resources: []*metav1.APIResourceList{
+				{GroupVersion: "networking.k8s.io/v1", APIResources: []metav1.APIResource{{Name: "ingresses", Namespaced: true}}},
+				{GroupVersion: "apps/v1", APIResources: []metav1.APIResource{{Name: "deployments", Namespaced: true}}},
+			},
+		},
+		Instance: &api.Instance{
+			TargetVersions:     map[string]string{"k8s": "v1.16.0"},
+			DeprecatedVersions: []api.Version{testVersionIngress},
+		},
+	}
+	err := cl.GetApiResources(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, []string{"ingresses"}, listed)
+	assert.Equal(t, []*api.Output{
+		{Name: "web", Namespace: "default", APIVersion: &testVersionIngress, Manager: "old-controller"},
+	}, cl.Instance.Outputs)
+}
@@ -29,9 +29,11 @@
 package finder
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"k8s.io/klog/v2"
 
@@ -43,23 +45,31 @@ type Dir struct {
 	RootPath string
 	FileList []string
 	Instance *api.Instance
+	// Workers is the number of files to scan concurrently.
+	// Values less than one are treated as one.
+	Workers int
+	// Include is a list of gitignore-style patterns. If set, only files matching one are scanned.
+	Include []string
+	// Exclude is a list of gitignore-style patterns for paths that should not be scanned.
+	Exclude []string
 }
 
 // NewFinder returns a new struct with config portions complete.
-func NewFinder(path string, instance *api.Instance) *Dir {
+// If path is blank, the working directory is used.
+func NewFinder(path string, instance *api.Instance) (*Dir, error) {
 	cfg := &Dir{
 		Instance: instance,
 	}
 	if path == "" {
 		cwd, err := os.Getwd()
 		if err != nil {
-			klog.Fatal(err)
+			return nil, fmt.Errorf("error getting the working directory: %w", err)
 		}
 		cfg.RootPath = cwd
 	} else {
 		cfg.RootPath = path
 	}
-	return cfg
+	return cfg, nil
 }
 
 // FindVersions runs the finder. This will populate the
@@ -69,6 +79,7 @@ func (dir *Dir) FindVersions() error {
 	if err != nil {
 		return err
 	}
+	dir.findCRDVersions()
 	err = dir.scanFiles()
 	if err != nil {
 		return err
@@ -81,17 +92,82 @@ func (dir *Dir) FindVersions() error {
 	return nil
 }
 
+// FindCRDs returns the CustomResourceDefinitions in the files in the directory
+func (dir *Dir) FindCRDs() ([]api.CustomResourceDefinition, error) {
+	err := dir.listFiles()
+	if err != nil {
+		return nil, err
+	}
+	results := make([][]api.CustomResourceDefinition, len(dir.FileList))
+	dir.forEachFile(func(i int, file string) {
+		data, err := os.ReadFile(file)
+		if err != nil || !bytes.Contains(data, []byte("CustomResourceDefinition")) {
+			return
+		}
+		crds, err := api.ReadCRDs(data)
+		if err != nil {
+			klog.V(2).Infof("error reading CustomResourceDefinitions in %s: %s", file, err.Error())
+			return
+		}
+		results[i] = crds
+	})
+	var crds []api.CustomResourceDefinition
+	for _, result := range results {
+		crds = append(crds, result...)
+	}
+	return crds, nil
+}
+
 // listFiles gets a list of all the files in the directory.
+// Paths matched by a .plutoignore file or by dir.Exclude are skipped, and
+// if dir.Include is set only files matching one of its patterns, or in a directory
+// that matches one, are listed.
 func (dir *Dir) listFiles() error {
 	var files []string
 
 	if _, err := os.Stat(dir.RootPath); os.IsNotExist(err) {
 		return fmt.Errorf("specified path does not exist")
 	}
-	err := filepath.Walk(dir.RootPath, func(path string, info os.FileInfo, err error) error {
-		if !info.IsDir() {
-			files = append(files, path)
+	excludes, err := newIgnoreRules(dir.RootPath, dir.Exclude)
+	if err != nil {
+		return err
+	}
+	includes, err := newIgnoreRules(dir.RootPath, dir.Include)
+	if err != nil {
+		return err
+	}
+	ignoreFiles := make(map[string]*ignoreRules)
+	err = filepath.Walk(dir.RootPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			klog.V(2).Infof("error walking path %s: %s", path, err.Error())
+			return nil
+		}
+		if dir.isIgnored(path, info.IsDir(), ignoreFiles, excludes) {
+			klog.V(8).Infof("ignoring path: %s", path)
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			rules, err := readIgnoreFile(path)
+			if err != nil {
+				return err
+			}
+			if rules != nil {
+				ignoreFiles[filepath.Clean(path)] = rules
+			}
+			return nil
 		}
+		if info.Name() == IgnoreFileName {
+			return nil
+		}
+		if len(includes.rules) > 0 {
+			if _, included := includes.matchPath(path, false); !included {
+				return nil
+			}
+		}
+		files = append(files, path)
 		return nil
 	})
 	if err != nil {
@@ -101,15 +177,118 @@ func (dir *Dir) listFiles() error {
 	return nil
 }
 
-// scanFiles loops through the file list and finds versioned files
-// to add to the dir struct
+// isIgnored checks the .plutoignore files of every parent directory of path,
+// from the root down, followed by the exclude patterns. The last match wins.
+func (dir *Dir) isIgnored(path string, isDir bool, ignoreFiles map[string]*ignoreRules, excludes *ignoreRules) bool {
+	root := filepath.Clean(dir.RootPath)
+	var parents []string
+	for d := filepath.Dir(path); ; d = filepath.Dir(d) {
+		parents = append(parents, d)
+		if d == root || d == filepath.Dir(d) {
+			break
+		}
+	}
+
+	ignored := false
+	for i := len(parents) - 1; i >= 0; i-- {
+		rules, ok := ignoreFiles[parents[i]]
+		if !ok {
+			continue
+		}
+		if matched, ig := rules.match(path, isDir); matched {
+			ignored = ig
+		}
+	}
+	if matched, ig := excludes.match(path, isDir); matched {
+		ignored = ig
+	}
+	return ignored
+}
+
+// readIgnoreFile loads the .plutoignore file in a directory, if there is one
+func readIgnoreFile(dirPath string) (*ignoreRules, error) {
+	f, err := os.Open(filepath.Join(dirPath, IgnoreFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	klog.V(3).Infof("using ignore file: %s", f.Name())
+	return parseIgnoreFile(filepath.Clean(dirPath), f)
+}
+
+// forEachFile calls fn for every file in the file list. Files are processed
+// concurrently by up to dir.Workers goroutines.
+func (dir *Dir) forEachFile(fn func(i int, file string)) {
+	workers := dir.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(dir.FileList) {
+		workers = len(dir.FileList)
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i, dir.FileList[i])
+			}
+		}()
+	}
+	for i := range dir.FileList {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+// findCRDVersions adds the deprecated versions that are served by any
+// CustomResourceDefinitions in the files to the instance, so custom resources in
+// other files are checked against them when the files are scanned. Only the
+// versions are kept, so the files are read again when they are scanned.
+func (dir *Dir) findCRDVersions() {
+	results := make([][]api.Version, len(dir.FileList))
+	deprecatedIn := dir.Instance.TargetVersions["k8s"]
+	dir.forEachFile(func(i int, file string) {
+		data, err := os.ReadFile(file)
+		if err != nil || !bytes.Contains(data, []byte("CustomResourceDefinition")) {
+			return
+		}
+		versions, err := api.CRDVersions(data, deprecatedIn)
+		if err != nil {
+			klog.V(2).Infof("error reading CustomResourceDefinitions in %s: %s", file, err.Error())
+			return
+		}
+		results[i] = versions
+	})
+	for _, versions := range results {
+		dir.Instance.AddDeprecatedVersions(versions)
+	}
+}
+
+// scanFiles loops through the file list and finds versioned files to add to
+// the dir struct. Files are read and scanned concurrently by up to dir.Workers
+// goroutines, but outputs are appended in FileList order so results are
+// deterministic.
 func (dir *Dir) scanFiles() error {
-	for _, file := range dir.FileList {
+	results := make([][]*api.Output, len(dir.FileList))
+	dir.forEachFile(func(i int, file string) {
 		klog.V(8).Infof("processing file: %s", file)
-		apiFile, err := dir.CheckForAPIVersion(file)
+		outputs, err := dir.CheckForAPIVersion(file)
 		if err != nil {
 			klog.V(2).Infof("error scanning file %s: %s", file, err.Error())
+			return
 		}
+		results[i] = outputs
+	})
+
+	for _, apiFile := range results {
 		if apiFile != nil {
 			dir.Instance.Outputs = append(dir.Instance.Outputs, apiFile...)
 		}
@@ -125,17 +304,24 @@ func (dir *Dir) CheckForAPIVersion(file string) ([]*api.Output, error) {
 	if err != nil {
 		return nil, err
 	}
-	outputs, err := dir.Instance.IsVersioned(data)
-	if err != nil {
-		return nil, err
-	}
+	return dir.checkData(file, data)
+}
 
-	cwd, err := os.Getwd()
+// checkData checks the data of a file for api-versioned Kubernetes objects
+func (dir *Dir) checkData(file string, data []byte) ([]*api.Output, error) {
+	outputs, err := dir.Instance.IsVersionedFile(data)
 	if err != nil {
 		return nil, err
 	}
 
-	filePath := fmt.Sprintf("%s/%s", cwd, file)
+	filePath := file
+	if !filepath.IsAbs(filePath) {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		filePath = filepath.Join(cwd, file)
+	}
 	for _, output := range outputs {
 		output.FilePath = filePath
 	}
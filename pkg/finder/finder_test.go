@@ -30,6 +30,7 @@ package finder
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/fairwindsops/pluto/v5/pkg/api"
@@ -42,6 +43,9 @@ var deploymentExtensionsV1Yaml = "testdata/deployment-extensions-v1beta1.yaml"
 var deploymentExtensionsV1YamlFile = []*api.Output{{
 	Name:      "utilities",
 	Namespace: "yaml-namespace",
+	Document:  1,
+	Line:      1,
+	Column:    1,
 	APIVersion: &api.Version{
 		Name:           "extensions/v1beta1",
 		Kind:           "Deployment",
@@ -56,6 +60,9 @@ var deploymentExtensionsV1JSON = "testdata/deployment-extensions-v1beta1.json"
 var deploymentExtensionsV1JSONFile = []*api.Output{{
 	Name:      "utilities",
 	Namespace: "json-namespace",
+	Document:  1,
+	Line:      2,
+	Column:    3,
 	APIVersion: &api.Version{
 		Name:           "extensions/v1beta1",
 		Kind:           "Deployment",
@@ -153,7 +160,7 @@ func TestNewFinder(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := NewFinder(tt.path, &api.Instance{
+			got, err := NewFinder(tt.path, &api.Instance{
 				TargetVersions: map[string]string{
 					"k8s":          "v1.16.0",
 					"istio":        "1.6.1",
@@ -167,6 +174,7 @@ func TestNewFinder(t *testing.T) {
 				OutputFormat:       "normal",
 			},
 			)
+			assert.NoError(t, err)
 			assert.Equal(t, tt.want, got)
 		})
 	}
@@ -247,6 +255,7 @@ func TestDir_scanFiles(t *testing.T) {
 	tests := []struct {
 		name     string
 		wantErr  bool
+		workers  int
 		fileList []string
 		want     []*api.Output
 	}{
@@ -256,10 +265,18 @@ func TestDir_scanFiles(t *testing.T) {
 			fileList: []string{deploymentExtensionsV1Yaml},
 			want:     deploymentExtensionsV1YamlFile,
 		},
+		{
+			name:     "multiple workers keep file order",
+			wantErr:  false,
+			workers:  4,
+			fileList: testFiles,
+			want:     testOutput,
+		},
 	}
-	dir := newMockFinder(testPath)
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			dir := newMockFinder(testPath)
+			dir.Workers = tt.workers
 			dir.FileList = tt.fileList
 			err := dir.scanFiles()
 			if tt.wantErr {
@@ -310,3 +327,196 @@ func TestDir_FindVersions(t *testing.T) {
 		})
 	}
 }
+
+func TestDir_FindVersionsWorkingDirectory(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(root, "manifests"), 0755))
+	data, err := os.ReadFile(deploymentExtensionsV1Yaml)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "manifests", "deployment.yaml"), data, 0644))
+	t.Chdir(root)
+	cwd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	// without -d, the working directory is scanned and the file list is absolute
+	dir, err := NewFinder("", newMockFinder("").Instance)
+	assert.NoError(t, err)
+	assert.NoError(t, dir.FindVersions())
+	if assert.Len(t, dir.Instance.Outputs, 1) {
+		assert.Equal(t, filepath.Join(cwd, "manifests", "deployment.yaml"), dir.Instance.Outputs[0].FilePath)
+	}
+	baseline := api.NewBaseline(dir.Instance.Outputs)
+	if assert.Len(t, baseline.Findings, 1) {
+		assert.Equal(t, "manifests/deployment.yaml", baseline.Findings[0].FilePath)
+	}
+
+	// with -d, the same file is found at the same path
+	dir, err = NewFinder("manifests", newMockFinder("").Instance)
+	assert.NoError(t, err)
+	assert.NoError(t, dir.FindVersions())
+	if assert.Len(t, dir.Instance.Outputs, 1) {
+		assert.True(t, baseline.Contains(dir.Instance.Outputs[0]))
+	}
+}
+
+func TestDir_listFilesIgnore(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".plutoignore":        "# skip vendored and json files\nvendor/\n*.json\n",
+		"a.yaml":              "",
+		"b.json":              "",
+		"vendor/c.yaml":       "",
+		"node_modules/f.yaml": "",
+		"sub/.plutoignore":    "!e.json\nd.yaml\n",
+		"sub/d.yaml":          "",
+		"sub/e.json":          "",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		want    []string
+	}{
+		{
+			name: "ignore files only",
+			want: []string{"a.yaml", "node_modules/f.yaml", "sub/e.json"},
+		},
+		{
+			name:    "exclude directory",
+			exclude: []string{"node_modules/"},
+			want:    []string{"a.yaml", "sub/e.json"},
+		},
+		{
+			name:    "exclude overrides ignore file",
+			exclude: []string{"!b.json"},
+			want:    []string{"a.yaml", "b.json", "node_modules/f.yaml", "sub/e.json"},
+		},
+		{
+			name:    "include yaml",
+			include: []string{"*.yaml"},
+			want:    []string{"a.yaml", "node_modules/f.yaml"},
+		},
+		{
+			name:    "include directory",
+			include: []string{"sub/"},
+			want:    []string{"sub/e.json"},
+		},
+		{
+			name:    "include directory without a slash",
+			include: []string{"sub"},
+			want:    []string{"sub/e.json"},
+		},
+		{
+			name:    "include anchored directory",
+			include: []string{"/node_modules"},
+			want:    []string{"node_modules/f.yaml"},
+		},
+		{
+			name:    "include directory glob",
+			include: []string{"sub/**"},
+			want:    []string{"sub/e.json"},
+		},
+		{
+			name:    "negated file in included directory",
+			include: []string{"node_modules/", "!f.yaml"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := newMockFinder(root)
+			dir.Include = tt.include
+			dir.Exclude = tt.exclude
+			err := dir.listFiles()
+			assert.NoError(t, err)
+			var got []string
+			for _, f := range dir.FileList {
+				rel, _ := filepath.Rel(root, f)
+				got = append(got, filepath.ToSlash(rel))
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDir_FindVersionsCRD(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"a-widget.yaml": "apiVersion: example.com/v1alpha1\nkind: Widget\nmetadata:\n  name: foo\n---\napiVersion: example.com/v1\nkind: Widget\nmetadata:\n  name: bar\n",
+		"crd.yaml": `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+  versions:
+  - name: v1alpha1
+    served: true
+    storage: false
+    deprecated: true
+    deprecationWarning: example.com/v1alpha1 Widget is deprecated
+  - name: v1
+    served: true
+    storage: true
+`,
+	}
+	for name, content := range files {
+		assert.NoError(t, os.WriteFile(filepath.Join(root, name), []byte(content), 0644))
+	}
+
+	dir := newMockFinder(root)
+	dir.Workers = 2
+	err := dir.FindVersions()
+	assert.NoError(t, err)
+	assert.Len(t, dir.Instance.Outputs, 1)
+	assert.Equal(t, "foo", dir.Instance.Outputs[0].Name)
+	assert.Equal(t, &api.Version{
+		Name:                   "example.com/v1alpha1",
+		Kind:                   "Widget",
+		DeprecatedIn:           "v1.16.0",
+		ReplacementAPI:         "example.com/v1",
+		ReplacementAvailableIn: "v1.16.0",
+		Component:              "k8s",
+	}, dir.Instance.Outputs[0].APIVersion)
+}
+
+func TestDir_findCRDVersions(t *testing.T) {
+	root := t.TempDir()
+	crd := "apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nspec:\n  group: example.com\n  names:\n    kind: Widget\n  versions:\n  - name: v1alpha1\n    served: true\n    deprecated: true\n  - name: v1\n    served: true\n    storage: true\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "crd.yaml"), []byte(crd), 0644))
+
+	dir := newMockFinder(root)
+	dir.FileList = []string{filepath.Join(root, "crd.yaml"), filepath.Join(root, "missing.yaml")}
+	dir.findCRDVersions()
+	assert.Contains(t, dir.Instance.DeprecatedVersions, api.Version{
+		Name:                   "example.com/v1alpha1",
+		Kind:                   "Widget",
+		DeprecatedIn:           "v1.16.0",
+		ReplacementAPI:         "example.com/v1",
+		ReplacementAvailableIn: "v1.16.0",
+		Component:              "k8s",
+	})
+}
+
+func TestDir_FindCRDs(t *testing.T) {
+	root := t.TempDir()
+	crd := "apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nspec:\n  group: example.com\n  names:\n    kind: Widget\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "crd.yaml"), []byte(crd), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "deployment.yaml"), []byte("apiVersion: apps/v1\nkind: Deployment\n"), 0644))
+
+	dir := newMockFinder(root)
+	crds, err := dir.FindCRDs()
+	assert.NoError(t, err)
+	assert.Len(t, crds, 1)
+	assert.Equal(t, "Widget", crds[0].Spec.Names.Kind)
+
+	_, err = newMockFinder("foo").FindCRDs()
+	assert.Error(t, err)
+}
@@ -0,0 +1,106 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"k8s.io/klog/v2"
+
+	"github.com/fairwindsops/pluto/v5/pkg/api"
+)
+
+// FileFix is a file with deprecated apiVersions rewritten to their replacements
+type FileFix struct {
+	// Path is the path of the file
+	Path string
+	// Original is the contents of the file before the fix
+	Original []byte
+	// Fixed is the contents of the file after the fix
+	Fixed []byte
+	// Outputs are the resources that were changed
+	Outputs []*api.Output
+}
+
+// FixVersions finds every file in the directory that contains apiVersions
+// that can be replaced and returns the fixed contents. Nothing is written.
+func (dir *Dir) FixVersions() ([]*FileFix, error) {
+	err := dir.listFiles()
+	if err != nil {
+		return nil, err
+	}
+	var fixes []*FileFix
+	for _, file := range dir.FileList {
+		klog.V(8).Infof("processing file: %s", file)
+		fix, err := dir.FixFile(file)
+		if err != nil {
+			klog.V(2).Infof("error fixing file %s: %s", file, err.Error())
+			continue
+		}
+		if fix != nil {
+			fixes = append(fixes, fix)
+		}
+	}
+	return fixes, nil
+}
+
+// FixFile rewrites the replaceable apiVersions in a single file.
+// Returns nil if there is nothing to change.
+func (dir *Dir) FixFile(file string) (*FileFix, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	fixed, outputs, err := dir.Instance.FixVersions(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(outputs) == 0 {
+		return nil, nil
+	}
+	for _, output := range outputs {
+		output.FilePath = file
+	}
+	return &FileFix{
+		Path:     file,
+		Original: data,
+		Fixed:    fixed,
+		Outputs:  outputs,
+	}, nil
+}
+
+// Diff returns a unified diff of the fix
+func (fix *FileFix) Diff() (string, error) {
+	path := strings.TrimPrefix(filepath.ToSlash(fix.Path), "/")
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(fix.Original)),
+		B:        difflib.SplitLines(string(fix.Fixed)),
+		FromFile: "a/" + path,
+		ToFile:   "b/" + path,
+		Context:  3,
+	})
+}
+
+// Write saves the fixed contents to the file, keeping its permissions
+func (fix *FileFix) Write() error {
+	info, err := os.Stat(fix.Path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(fix.Path, fix.Fixed, info.Mode().Perm())
+}
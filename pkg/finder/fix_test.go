@@ -0,0 +1,55 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDir_FixVersions(t *testing.T) {
+	root := t.TempDir()
+	deprecated := filepath.Join(root, "deployment.yaml")
+	assert.NoError(t, os.WriteFile(deprecated, []byte("apiVersion: extensions/v1beta1\nkind: Deployment\nmetadata:\n  name: utilities\n"), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "current.yaml"), []byte("apiVersion: apps/v1\nkind: Deployment\n"), 0644))
+
+	dir := newMockFinder(root)
+	dir.Instance.TargetVersions = map[string]string{"k8s": "v1.16.0"}
+	dir.Instance.Components = []string{"k8s"}
+	dir.Instance.DeprecatedVersions[0].ReplacementAvailableIn = "v1.9.0"
+
+	fixes, err := dir.FixVersions()
+	assert.NoError(t, err)
+	assert.Len(t, fixes, 1)
+	fix := fixes[0]
+	assert.Equal(t, deprecated, fix.Path)
+	assert.Len(t, fix.Outputs, 1)
+	assert.Equal(t, "utilities", fix.Outputs[0].Name)
+
+	diff, err := fix.Diff()
+	assert.NoError(t, err)
+	assert.Contains(t, diff, "-apiVersion: extensions/v1beta1\n+apiVersion: apps/v1\n")
+
+	assert.NoError(t, fix.Write())
+	data, err := os.ReadFile(deprecated)
+	assert.NoError(t, err)
+	assert.Equal(t, "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: utilities\n", string(data))
+	info, err := os.Stat(deprecated)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
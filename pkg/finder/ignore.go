@@ -0,0 +1,186 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFileName is the name of the file that lists paths to skip.
+// It uses gitignore syntax and is honored in every directory that is walked.
+const IgnoreFileName = ".plutoignore"
+
+// ignoreRule is a single compiled gitignore-style pattern
+type ignoreRule struct {
+	pattern *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreRules is a list of patterns that are relative to base
+type ignoreRules struct {
+	base  string
+	rules []ignoreRule
+}
+
+// parseIgnoreFile reads gitignore-style patterns from r.
+// Patterns are matched relative to base.
+func parseIgnoreFile(base string, r io.Reader) (*ignoreRules, error) {
+	var patterns []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		patterns = append(patterns, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return newIgnoreRules(base, patterns)
+}
+
+// newIgnoreRules compiles a list of gitignore-style patterns relative to base.
+// Blank lines and comments are skipped.
+func newIgnoreRules(base string, patterns []string) (*ignoreRules, error) {
+	ir := &ignoreRules{base: base}
+	for _, p := range patterns {
+		p = strings.TrimRight(p, " \t\r")
+		if p == "" || strings.HasPrefix(p, "#") {
+			continue
+		}
+		rule := ignoreRule{}
+		if strings.HasPrefix(p, "!") {
+			rule.negate = true
+			p = p[1:]
+		} else if strings.HasPrefix(p, `\`) {
+			p = p[1:]
+		}
+		if strings.HasSuffix(p, "/") {
+			rule.dirOnly = true
+			p = strings.TrimSuffix(p, "/")
+		}
+		if p == "" {
+			continue
+		}
+		// A pattern with a slash is anchored to the base, otherwise it can match at any depth.
+		anchored := strings.Contains(p, "/")
+		p = strings.TrimPrefix(p, "/")
+		expr := globToRegexp(p)
+		if anchored {
+			expr = "^" + expr + "$"
+		} else {
+			expr = "^(?:.*/)?" + expr + "$"
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ignore pattern %q: %w", p, err)
+		}
+		rule.pattern = re
+		ir.rules = append(ir.rules, rule)
+	}
+	return ir, nil
+}
+
+// match reports whether any rule matches path, and if so whether the
+// path is ignored. The last matching rule wins.
+func (ir *ignoreRules) match(path string, isDir bool) (matched bool, ignored bool) {
+	rel, err := filepath.Rel(ir.base, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false, false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, rule := range ir.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.pattern.MatchString(rel) {
+			matched = true
+			ignored = !rule.negate
+		}
+	}
+	return matched, ignored
+}
+
+// matchPath is like match, but the parent directories of path below the base are
+// matched too, so a pattern that matches a directory matches everything in it, like
+// gitignore. The deepest match wins, so a file in a matching directory can still be
+// negated.
+func (ir *ignoreRules) matchPath(path string, isDir bool) (matched bool, ignored bool) {
+	rel, err := filepath.Rel(ir.base, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false, false
+	}
+	parent := ir.base
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for _, part := range parts[:len(parts)-1] {
+		parent = filepath.Join(parent, part)
+		if m, ig := ir.match(parent, true); m {
+			matched, ignored = true, ig
+		}
+	}
+	if m, ig := ir.match(path, isDir); m {
+		matched, ignored = true, ig
+	}
+	return matched, ignored
+}
+
+// globToRegexp converts a gitignore glob into a regular expression
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				atStart := i == 0 || glob[i-1] == '/'
+				i++
+				if atStart && i+1 < len(glob) && glob[i+1] == '/' {
+					// "**/" matches zero or more directories
+					i++
+					sb.WriteString("(?:.*/)?")
+				} else {
+					sb.WriteString(".*")
+				}
+				continue
+			}
+			sb.WriteString("[^/]*")
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				sb.WriteString(regexp.QuoteMeta(string(glob[i])))
+			}
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
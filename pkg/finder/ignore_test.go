@@ -0,0 +1,164 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ignoreRules_match(t *testing.T) {
+	tests := []struct {
+		name        string
+		patterns    string
+		path        string
+		isDir       bool
+		wantMatched bool
+		wantIgnored bool
+	}{
+		{
+			name:        "basename at any depth",
+			patterns:    "*.json",
+			path:        "/root/a/b/file.json",
+			wantMatched: true,
+			wantIgnored: true,
+		},
+		{
+			name:     "comment and blank lines",
+			patterns: "# *.json\n\n",
+			path:     "/root/file.json",
+		},
+		{
+			name:     "anchored pattern does not match deeper",
+			patterns: "/file.yaml",
+			path:     "/root/sub/file.yaml",
+		},
+		{
+			name:        "anchored pattern matches at root",
+			patterns:    "/file.yaml",
+			path:        "/root/file.yaml",
+			wantMatched: true,
+			wantIgnored: true,
+		},
+		{
+			name:     "directory only pattern skips files",
+			patterns: "charts/",
+			path:     "/root/charts",
+		},
+		{
+			name:        "directory only pattern matches directories",
+			patterns:    "charts/",
+			path:        "/root/a/charts",
+			isDir:       true,
+			wantMatched: true,
+			wantIgnored: true,
+		},
+		{
+			name:        "double star matches nested directories",
+			patterns:    "a/**/test.yaml",
+			path:        "/root/a/b/c/test.yaml",
+			wantMatched: true,
+			wantIgnored: true,
+		},
+		{
+			name:        "double star matches zero directories",
+			patterns:    "a/**/test.yaml",
+			path:        "/root/a/test.yaml",
+			wantMatched: true,
+			wantIgnored: true,
+		},
+		{
+			name:        "negation re-includes",
+			patterns:    "*.yaml\n!keep.yaml",
+			path:        "/root/keep.yaml",
+			wantMatched: true,
+			wantIgnored: false,
+		},
+		{
+			name:        "character class",
+			patterns:    "file[0-9].yaml",
+			path:        "/root/file1.yaml",
+			wantMatched: true,
+			wantIgnored: true,
+		},
+		{
+			name:     "outside of base",
+			patterns: "*.yaml",
+			path:     "/other/file.yaml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := parseIgnoreFile("/root", strings.NewReader(tt.patterns))
+			assert.NoError(t, err)
+			matched, ignored := rules.match(tt.path, tt.isDir)
+			assert.Equal(t, tt.wantMatched, matched)
+			assert.Equal(t, tt.wantIgnored, ignored)
+		})
+	}
+}
+
+func Test_ignoreRules_matchPath(t *testing.T) {
+	tests := []struct {
+		name        string
+		patterns    string
+		path        string
+		wantMatched bool
+		wantIgnored bool
+	}{
+		{
+			name:        "directory only pattern matches files in the directory",
+			patterns:    "charts/",
+			path:        "/root/a/charts/templates/deployment.yaml",
+			wantMatched: true,
+			wantIgnored: true,
+		},
+		{
+			name:        "anchored directory",
+			patterns:    "/charts",
+			path:        "/root/charts/deployment.yaml",
+			wantMatched: true,
+			wantIgnored: true,
+		},
+		{
+			name:     "anchored directory does not match deeper",
+			patterns: "/charts",
+			path:     "/root/a/charts/deployment.yaml",
+		},
+		{
+			name:        "file negated in a matching directory",
+			patterns:    "charts/\n!deployment.yaml",
+			path:        "/root/charts/deployment.yaml",
+			wantMatched: true,
+			wantIgnored: false,
+		},
+		{
+			name:     "base is not matched",
+			patterns: "root/",
+			path:     "/root/deployment.yaml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := parseIgnoreFile("/root", strings.NewReader(tt.patterns))
+			assert.NoError(t, err)
+			matched, ignored := rules.matchPath(tt.path, false)
+			assert.Equal(t, tt.wantMatched, matched)
+			assert.Equal(t, tt.wantIgnored, ignored)
+		})
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/cli/values"
+	"helm.sh/helm/v3/pkg/engine"
+	"helm.sh/helm/v3/pkg/getter"
+	"k8s.io/klog/v2"
+
+	"github.com/fairwindsops/pluto/v5/pkg/api"
+)
+
+// ChartOptions are the options used to render a local chart
+type ChartOptions struct {
+	// ReleaseName is the name of the release used when rendering
+	ReleaseName string
+	// ValueFiles is a list of values files, like helm's --values
+	ValueFiles []string
+	// Values is a list of key=value pairs, like helm's --set
+	Values []string
+	// KubeVersion is the Kubernetes version of the capabilities used when rendering.
+	// If blank, helm's default is used.
+	KubeVersion string
+	// APIVersions are additional api versions added to the capabilities used when rendering
+	APIVersions []string
+}
+
+// NewHelmForChart returns a helm struct that renders local charts and does not
+// connect to a cluster
+func NewHelmForChart(namespace string, instance *api.Instance) *Helm {
+	return &Helm{
+		Namespace: namespace,
+		Instance:  instance,
+	}
+}
+
+// FindChartVersions renders a chart directory or archive and checks each
+// rendered template for versions. The template path is used as the file path.
+func (h *Helm) FindChartVersions(chartPath string, options ChartOptions) error {
+	rendered, err := h.renderChart(chartPath, options)
+	if err != nil {
+		return err
+	}
+
+	templates := make([]string, 0, len(rendered))
+	for name := range rendered {
+		templates = append(templates, name)
+	}
+	sort.Strings(templates)
+
+	for _, name := range templates {
+		ext := path.Ext(name)
+		if ext != ".yaml" && ext != ".yml" && ext != ".json" {
+			continue
+		}
+		manifest := strings.TrimSpace(rendered[name])
+		if manifest == "" {
+			continue
+		}
+		klog.V(2).Infof("parsing template %s", name)
+		outputs, err := h.Instance.IsVersionedFile([]byte(manifest))
+		if err != nil {
+			return fmt.Errorf("error parsing template '%s'\n   %w", name, err)
+		}
+		for _, out := range outputs {
+			out.FilePath = name
+		}
+		h.Instance.Outputs = append(h.Instance.Outputs, outputs...)
+	}
+	return nil
+}
+
+// renderChart loads a chart and renders its templates with the values and capabilities in options
+func (h *Helm) renderChart(chartPath string, options ChartOptions) (map[string]string, error) {
+	chrt, err := loader.Load(chartPath)
+	if err != nil {
+		return nil, fmt.Errorf("error loading chart %s: %w", chartPath, err)
+	}
+
+	valueOpts := &values.Options{
+		ValueFiles: options.ValueFiles,
+		Values:     options.Values,
+	}
+	vals, err := valueOpts.MergeValues(getter.All(cli.New()))
+	if err != nil {
+		return nil, fmt.Errorf("error reading values: %w", err)
+	}
+	if err := chartutil.ProcessDependenciesWithMerge(chrt, vals); err != nil {
+		return nil, fmt.Errorf("error processing chart dependencies: %w", err)
+	}
+
+	caps := chartutil.DefaultCapabilities.Copy()
+	if options.KubeVersion != "" {
+		kubeVersion, err := chartutil.ParseKubeVersion(options.KubeVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid kube version %s: %w", options.KubeVersion, err)
+		}
+		caps.KubeVersion = *kubeVersion
+	}
+	caps.APIVersions = append(append(chartutil.VersionSet{}, caps.APIVersions...), options.APIVersions...)
+
+	releaseName := options.ReleaseName
+	if releaseName == "" {
+		releaseName = "release-name"
+	}
+	namespace := h.Namespace
+	if namespace == "" {
+		namespace = "default"
+	}
+	releaseOptions := chartutil.ReleaseOptions{
+		Name:      releaseName,
+		Namespace: namespace,
+		Revision:  1,
+		IsInstall: true,
+	}
+	renderValues, err := chartutil.ToRenderValues(chrt, vals, releaseOptions, caps)
+	if err != nil {
+		return nil, err
+	}
+	rendered, err := engine.Render(chrt, renderValues)
+	if err != nil {
+		return nil, fmt.Errorf("error rendering chart %s: %w", chartPath, err)
+	}
+	return rendered, nil
+}
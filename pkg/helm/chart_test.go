@@ -0,0 +1,93 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"testing"
+
+	"github.com/fairwindsops/pluto/v5/pkg/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHelm_FindChartVersions(t *testing.T) {
+	deprecated := &api.Version{Name: "extensions/v1beta1", Kind: "Deployment", DeprecatedIn: "v1.9.0", RemovedIn: "v1.16.0", ReplacementAPI: "apps/v1", Component: "k8s"}
+	current := &api.Version{Name: "apps/v1", Kind: "Deployment", Component: "k8s"}
+
+	tests := []struct {
+		name      string
+		chartPath string
+		options   ChartOptions
+		want      []*api.Output
+		wantErr   bool
+	}{
+		{
+			name:      "defaults",
+			chartPath: "testdata/chart",
+			want: []*api.Output{
+				{Name: "release-name-testchart", Namespace: "default", FilePath: "testchart/templates/deployment.yaml", Document: 1, Line: 1, Column: 1, APIVersion: current},
+			},
+		},
+		{
+			name:      "old kube version",
+			chartPath: "testdata/chart",
+			options:   ChartOptions{KubeVersion: "v1.8.0"},
+			want: []*api.Output{
+				{Name: "release-name-testchart", Namespace: "default", FilePath: "testchart/templates/deployment.yaml", Document: 1, Line: 1, Column: 1, APIVersion: deprecated},
+			},
+		},
+		{
+			name:      "values file",
+			chartPath: "testdata/chart",
+			options:   ChartOptions{ReleaseName: "foo", ValueFiles: []string{"testdata/legacy-values.yaml"}},
+			want: []*api.Output{
+				{Name: "foo-testchart", Namespace: "default", FilePath: "testchart/templates/deployment.yaml", Document: 1, Line: 1, Column: 1, APIVersion: current},
+				{Name: "foo-testchart-legacy", Namespace: "default", FilePath: "testchart/templates/legacy.yaml", Document: 1, Line: 1, Column: 1, APIVersion: deprecated},
+			},
+		},
+		{
+			name:      "set values",
+			chartPath: "testdata/chart",
+			options:   ChartOptions{Values: []string{"legacy.enabled=true"}},
+			want: []*api.Output{
+				{Name: "release-name-testchart", Namespace: "default", FilePath: "testchart/templates/deployment.yaml", Document: 1, Line: 1, Column: 1, APIVersion: current},
+				{Name: "release-name-testchart-legacy", Namespace: "default", FilePath: "testchart/templates/legacy.yaml", Document: 1, Line: 1, Column: 1, APIVersion: deprecated},
+			},
+		},
+		{
+			name:      "invalid kube version",
+			chartPath: "testdata/chart",
+			options:   ChartOptions{KubeVersion: "foo"},
+			wantErr:   true,
+		},
+		{
+			name:      "missing chart",
+			chartPath: "testdata/missing",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newMockHelm("")
+			h.Kube = nil
+			err := h.FindChartVersions(tt.chartPath, tt.options)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, h.Instance.Outputs)
+		})
+	}
+}
@@ -73,9 +73,11 @@ type ChartMeta struct {
 	Version string `json:"version"`
 }
 
-// NewHelm returns a basic helm struct with the version of helm requested
-func NewHelm(namespace string, kubeContext string, instance *api.Instance, kubeConfigPath string) (*Helm, error) {
-	config, err := kube.GetConfigInstance(kubeContext, kubeConfigPath)
+// NewHelm returns a basic helm struct with the version of helm requested. Every request
+// to the cluster, including the ones made by the helm storage driver, is cancelled when
+// ctx is done.
+func NewHelm(ctx context.Context, namespace string, kubeContext string, instance *api.Instance, kubeConfigPath string) (*Helm, error) {
+	config, err := kube.GetConfigInstance(ctx, kubeContext, kubeConfigPath)
 	if err != nil {
 		return nil, err
 	}
@@ -102,15 +104,15 @@ func NewHelmWithKubeClient(version string, store string, namespace string, insta
 // FindVersions is the primary method in the package.
 // As of helm 2 being deprecated, this is just a passthrough to getReleasesVersionThree. It has been
 // left in place to ensure api backward compatibility.
-func (h *Helm) FindVersions() error {
-	return h.getReleasesVersionThree()
+func (h *Helm) FindVersions(ctx context.Context) error {
+	return h.getReleasesVersionThree(ctx)
 }
 
 // getReleasesVersionThree retrieves helm 3 releases from Secrets
-func (h *Helm) getReleasesVersionThree() error {
+func (h *Helm) getReleasesVersionThree(ctx context.Context) error {
 	hs := driverv3.NewSecrets(h.Kube.Client.CoreV1().Secrets(h.Namespace))
 	helmClient := helmstoragev3.Init(hs)
-	namespaces, err := h.Kube.Client.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
+	namespaces, err := h.Kube.Client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return err
 	}
@@ -118,6 +120,7 @@ func (h *Helm) getReleasesVersionThree() error {
 	if err != nil {
 		return err
 	}
+
 	for _, namespace := range namespaces.Items {
 		ns := namespace.Name
 		if h.Namespace != "" && ns != h.Namespace {
@@ -276,7 +276,7 @@ func TestHelm_getManifestsVersionThree(t *testing.T) {
 			}
 		}
 		t.Run(tt.name, func(t *testing.T) {
-			err := h.getReleasesVersionThree()
+			err := h.getReleasesVersionThree(context.Background())
 			if tt.wantErr {
 				assert.EqualError(t, err, tt.errMessage)
 				return
@@ -326,7 +326,7 @@ func TestHelm_getManifestsVersionThreeMultipleDeployed(t *testing.T) {
 
 		}
 		t.Run(tt.name, func(t *testing.T) {
-			err := h.getReleasesVersionThree()
+			err := h.getReleasesVersionThree(context.Background())
 			if tt.wantErr {
 				assert.EqualError(t, err, tt.errMessage)
 				return
@@ -357,7 +357,7 @@ func TestHelm_getManifest_badClient(t *testing.T) {
 			Kube: newBadKubeClient(),
 		}
 		t.Run(tt.name, func(t *testing.T) {
-			err := h.getReleasesVersionThree()
+			err := h.getReleasesVersionThree(context.Background())
 			if tt.wantErr {
 				assert.Error(t, err)
 				assert.Contains(t, err.Error(), "connect: connection refused")
@@ -0,0 +1,72 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"k8s.io/client-go/rest"
+)
+
+// BindContext cancels every request made with config when ctx is done. Discovery and the
+// Helm storage driver do not take a context, so this is the only way to stop their calls.
+func BindContext(ctx context.Context, config *rest.Config) {
+	if ctx.Done() == nil {
+		return
+	}
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &contextRoundTripper{ctx: ctx, next: rt}
+	})
+}
+
+// contextRoundTripper cancels a request, and the reading of its response, when ctx is done
+type contextRoundTripper struct {
+	ctx  context.Context
+	next http.RoundTripper
+}
+
+// RoundTrip sends the request with a context that is also cancelled when ctx is done
+func (rt *contextRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancelCause(req.Context())
+	stop := context.AfterFunc(rt.ctx, func() {
+		cancel(rt.ctx.Err())
+	})
+	done := func() {
+		stop()
+		cancel(nil)
+	}
+	resp, err := rt.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		done()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, done: done}
+	return resp, nil
+}
+
+// cancelOnClose releases the context of a request when its response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	done func()
+}
+
+// Close closes the body and releases the context of the request
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.done()
+	return err
+}
@@ -0,0 +1,91 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+)
+
+func TestBindContext(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"gitVersion": "v1.27.3"}`))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	config := &rest.Config{Host: server.URL}
+	BindContext(ctx, config)
+	client, err := discovery.NewDiscoveryClientForConfig(config)
+	assert.NoError(t, err)
+
+	errs := make(chan error)
+	go func() {
+		// ServerVersion does not take a context
+		_, err := client.ServerVersion()
+		errs <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-errs:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(5 * time.Second):
+		t.Fatal("request was not cancelled")
+	}
+}
+
+func TestBindContext_done(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"gitVersion": "v1.27.3"}`))
+	}))
+	defer server.Close()
+
+	config := &rest.Config{Host: server.URL}
+	BindContext(context.Background(), config)
+	client, err := discovery.NewDiscoveryClientForConfig(config)
+	assert.NoError(t, err)
+	version, err := GetServerVersion(client)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.27.3", version)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	config = &rest.Config{Host: server.URL}
+	BindContext(ctx, config)
+	client, err = discovery.NewDiscoveryClientForConfig(config)
+	assert.NoError(t, err)
+	version, err = GetServerVersion(client)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.27.3", version)
+
+	cancel()
+	_, err = GetServerVersion(client)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -29,11 +29,14 @@
 package kube
 
 import (
+	"context"
 	"flag"
+	"sort"
 	"sync"
 
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/klog/v2"
 
 	// This is required to auth to cloud providers (i.e. GKE)
@@ -41,41 +44,40 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 )
 
+// Kube is a client for a single cluster
 type Kube struct {
 	Client kubernetes.Interface
+	// Warnings collects the warnings returned by the API server for Client
+	Warnings *WarningCollector
 }
 
-var kubeClient *Kube
-var once sync.Once
+// configMu serializes GetConfig, since the kubeconfig flag of controller-runtime is a package variable
+var configMu sync.Mutex
 
-// GetConfigInstance returns a Pluto Kubernetes interface based on the current configuration
-func GetConfigInstance(kubeContext string, kubeConfigPath string) (*Kube, error) {
-	var err error
-	var client kubernetes.Interface
-	var kubeConfig *rest.Config
-
-	kubeConfig, err = GetConfig(kubeContext, kubeConfigPath)
+// GetConfigInstance returns a Pluto Kubernetes interface for a kube context. Each call returns
+// a new client, so clients for different contexts can be used at the same time. Every request
+// that the client makes is cancelled when ctx is done.
+func GetConfigInstance(ctx context.Context, kubeContext string, kubeConfigPath string) (*Kube, error) {
+	kubeConfig, err := GetConfig(kubeContext, kubeConfigPath)
 	if err != nil {
 		return nil, err
 	}
-
-	once.Do(func() {
-		if kubeClient == nil {
-			client, err = GetKubeClient(kubeConfig)
-
-			kubeClient = &Kube{
-				Client: client,
-			}
-		}
-	})
+	BindContext(ctx, kubeConfig)
+	client, err := GetKubeClient(kubeConfig)
 	if err != nil {
 		return nil, err
 	}
-	return kubeClient, nil
+	return &Kube{
+		Client:   client,
+		Warnings: Warnings(kubeConfig),
+	}, nil
 }
 
-// GetConfig returns the current kube config with a specific context
+// GetConfig returns the current kube config with a specific context. Warnings returned by
+// the API server are collected by a WarningCollector for the config, see Warnings.
 func GetConfig(kubeContext string, kubeConfigPath string) (*rest.Config, error) {
+	configMu.Lock()
+	defer configMu.Unlock()
 
 	if kubeContext != "" {
 		klog.V(3).Infof("using kube context: %s", kubeContext)
@@ -89,10 +91,30 @@ func GetConfig(kubeContext string, kubeConfigPath string) (*rest.Config, error)
 	if err != nil {
 		return nil, err
 	}
+	kubeConfig.WarningHandler = &WarningCollector{}
 
 	return kubeConfig, nil
 }
 
+// Contexts returns the names of the contexts in the kubeconfig, sorted. If kubeConfigPath
+// is blank, the default kubeconfig files are used.
+func Contexts(kubeConfigPath string) ([]string, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeConfigPath != "" {
+		loadingRules.ExplicitPath = kubeConfigPath
+	}
+	rawConfig, err := loadingRules.Load()
+	if err != nil {
+		return nil, err
+	}
+	contexts := make([]string, 0, len(rawConfig.Contexts))
+	for name := range rawConfig.Contexts {
+		contexts = append(contexts, name)
+	}
+	sort.Strings(contexts)
+	return contexts, nil
+}
+
 // GetKubeClient returns a Kubernetes.Interface based on the current configuration
 func GetKubeClient(kubeConfig *rest.Config) (kubernetes.Interface, error) {
 	clientset, err := kubernetes.NewForConfig(kubeConfig)
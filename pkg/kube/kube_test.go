@@ -15,6 +15,7 @@
 package kube
 
 import (
+	"context"
 	"os"
 	"testing"
 
@@ -85,3 +86,27 @@ func Test_getKubeClient(t *testing.T) {
 		})
 	}
 }
+
+func TestContexts(t *testing.T) {
+	got, err := Contexts("testdata/kubeconfig_multi")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"production", "staging"}, got)
+
+	_, err = Contexts("testdata/kubeconfig_invalid")
+	assert.Error(t, err)
+}
+
+func TestGetConfigInstance(t *testing.T) {
+	staging, err := GetConfigInstance(context.Background(), "staging", "testdata/kubeconfig_multi")
+	assert.NoError(t, err)
+	production, err := GetConfigInstance(context.Background(), "production", "testdata/kubeconfig_multi")
+	assert.NoError(t, err)
+
+	assert.NotSame(t, staging, production)
+	assert.NotSame(t, staging.Warnings, production.Warnings)
+	assert.NotNil(t, staging.Warnings)
+
+	config, err := GetConfig("production", "testdata/kubeconfig_multi")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://127.0.0.1:7443", config.Host)
+}
@@ -0,0 +1,68 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/semver"
+	"k8s.io/client-go/discovery"
+	"k8s.io/klog/v2"
+)
+
+// ServerVersion returns the Kubernetes version of the cluster as vMAJOR.MINOR.PATCH.
+// Any pre-release or build metadata, like v1.27.3-eks-a5565ad, is dropped so the
+// version compares equal to the upstream release.
+func (k *Kube) ServerVersion() (string, error) {
+	return GetServerVersion(k.Client.Discovery())
+}
+
+// GetServerVersion returns the Kubernetes version from a discovery client as vMAJOR.MINOR.PATCH
+func GetServerVersion(d discovery.ServerVersionInterface) (string, error) {
+	info, err := d.ServerVersion()
+	if err != nil {
+		return "", fmt.Errorf("error getting server version: %w", err)
+	}
+	klog.V(3).Infof("server version: %s", info.GitVersion)
+
+	version := semver.Canonical(info.GitVersion)
+	if version == "" {
+		return "", fmt.Errorf("server version %s is not valid semver", info.GitVersion)
+	}
+	return strings.TrimSuffix(version, semver.Prerelease(version)), nil
+}
+
+// UpgradeVersion returns the release that is a number of minor versions after version,
+// for example v1.27.3 upgraded by 2 is v1.29.0. The number may be written as +N.
+func UpgradeVersion(version string, upgrade string) (string, error) {
+	if !semver.IsValid(version) {
+		return "", fmt.Errorf("version %s is not valid semver", version)
+	}
+	minors, err := strconv.Atoi(strings.TrimPrefix(upgrade, "+"))
+	if err != nil || minors < 0 {
+		return "", fmt.Errorf("upgrade must be a number of minor versions like +1 - got %s", upgrade)
+	}
+	if minors == 0 {
+		return version, nil
+	}
+	parts := strings.Split(strings.TrimPrefix(semver.MajorMinor(version), "v"), ".")
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("version %s has an invalid minor version", version)
+	}
+	return fmt.Sprintf("v%s.%d.0", parts[0], minor+minors), nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestKube_ServerVersion(t *testing.T) {
+	tests := []struct {
+		name       string
+		gitVersion string
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:       "release",
+			gitVersion: "v1.27.3",
+			want:       "v1.27.3",
+		},
+		{
+			name:       "pre-release and build metadata",
+			gitVersion: "v1.27.3-eks-a5565ad+abc123",
+			want:       "v1.27.3",
+		},
+		{
+			name:       "invalid",
+			gitVersion: "1.27",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			client.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{GitVersion: tt.gitVersion}
+			k := &Kube{Client: client}
+			got, err := k.ServerVersion()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestUpgradeVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		upgrade string
+		want    string
+		wantErr bool
+	}{
+		{name: "zero", version: "v1.27.3", upgrade: "+0", want: "v1.27.3"},
+		{name: "plus one", version: "v1.27.3", upgrade: "+1", want: "v1.28.0"},
+		{name: "without plus", version: "v1.27.3", upgrade: "2", want: "v1.29.0"},
+		{name: "negative", version: "v1.27.3", upgrade: "-1", wantErr: true},
+		{name: "not a number", version: "v1.27.3", upgrade: "+x", wantErr: true},
+		{name: "invalid version", version: "1.27.3", upgrade: "+1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UpgradeVersion(tt.version, tt.upgrade)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
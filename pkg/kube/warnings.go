@@ -0,0 +1,111 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"regexp"
+	"sync"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+
+	"github.com/fairwindsops/pluto/v5/pkg/api"
+)
+
+// deprecationWarning matches the warnings that the API server returns for deprecated apiVersions, like
+// "extensions/v1beta1 Ingress is deprecated in v1.14+, unavailable in v1.22+; use networking.k8s.io/v1 Ingress"
+// Custom resources use the same format without the Kubernetes versions.
+var deprecationWarning = regexp.MustCompile(`^(\S+) (\S+) is deprecated(?: in v(\d+\.\d+)\+)?(?:, unavailable in v(\d+\.\d+)\+)?(?:; use (\S+) \S+)?$`)
+
+// WarningCollector is a rest.WarningHandler that keeps the warnings returned by the API server
+type WarningCollector struct {
+	mu       sync.Mutex
+	warnings []string
+}
+
+// Warnings returns the WarningCollector of a config returned by GetConfig, or nil
+func Warnings(config *rest.Config) *WarningCollector {
+	collector, _ := config.WarningHandler.(*WarningCollector)
+	return collector
+}
+
+// HandleWarningHeader keeps a warning until the next call to Drain
+func (c *WarningCollector) HandleWarningHeader(code int, agent string, text string) {
+	if code != 299 || text == "" {
+		return
+	}
+	klog.V(2).Infof("warning from the API server: %s", text)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, warning := range c.warnings {
+		if warning == text {
+			return
+		}
+	}
+	c.warnings = append(c.warnings, text)
+}
+
+// Drain returns the warnings collected since the last call to Drain
+func (c *WarningCollector) Drain() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	drained := c.warnings
+	c.warnings = nil
+	return drained
+}
+
+// DeprecatedVersions returns a version for each deprecation warning that the API server returned
+// since the last call. Custom resources do not say which Kubernetes version they were deprecated
+// in, so they are deprecated in targetVersion, which is the k8s target version.
+// A nil WarningCollector has no warnings.
+func (c *WarningCollector) DeprecatedVersions(targetVersion string) []api.Version {
+	if c == nil {
+		return nil
+	}
+	var versions []api.Version
+	for _, warning := range c.Drain() {
+		version, ok := parseDeprecationWarning(warning)
+		if !ok {
+			klog.V(3).Infof("skipping warning that is not a deprecation: %s", warning)
+			continue
+		}
+		if version.DeprecatedIn == "" {
+			version.DeprecatedIn = targetVersion
+		}
+		versions = append(versions, version)
+	}
+	return versions
+}
+
+// parseDeprecationWarning returns the version for a deprecation warning from the API server
+func parseDeprecationWarning(text string) (api.Version, bool) {
+	match := deprecationWarning.FindStringSubmatch(text)
+	if match == nil {
+		return api.Version{}, false
+	}
+	version := api.Version{
+		Name:           match[1],
+		Kind:           match[2],
+		ReplacementAPI: match[5],
+		Component:      "k8s",
+	}
+	if match[3] != "" {
+		version.DeprecatedIn = "v" + match[3] + ".0"
+	}
+	if match[4] != "" {
+		version.RemovedIn = "v" + match[4] + ".0"
+	}
+	return version, true
+}
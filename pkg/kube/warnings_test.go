@@ -0,0 +1,98 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/fairwindsops/pluto/v5/pkg/api"
+)
+
+func Test_parseDeprecationWarning(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		want   api.Version
+		wantOk bool
+	}{
+		{
+			name: "removed with replacement",
+			text: "extensions/v1beta1 Ingress is deprecated in v1.14+, unavailable in v1.22+; use networking.k8s.io/v1 Ingress",
+			want: api.Version{
+				Name:           "extensions/v1beta1",
+				Kind:           "Ingress",
+				DeprecatedIn:   "v1.14.0",
+				RemovedIn:      "v1.22.0",
+				ReplacementAPI: "networking.k8s.io/v1",
+				Component:      "k8s",
+			},
+			wantOk: true,
+		},
+		{
+			name: "core group without removal",
+			text: "v1 ComponentStatus is deprecated in v1.19+",
+			want: api.Version{
+				Name:         "v1",
+				Kind:         "ComponentStatus",
+				DeprecatedIn: "v1.19.0",
+				Component:    "k8s",
+			},
+			wantOk: true,
+		},
+		{
+			name: "custom resource",
+			text: "example.com/v1alpha1 Widget is deprecated; use example.com/v1 Widget",
+			want: api.Version{
+				Name:           "example.com/v1alpha1",
+				Kind:           "Widget",
+				ReplacementAPI: "example.com/v1",
+				Component:      "k8s",
+			},
+			wantOk: true,
+		},
+		{
+			name: "custom warning",
+			text: "widgets are going away soon",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseDeprecationWarning(tt.text)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWarningCollector_DeprecatedVersions(t *testing.T) {
+	warnings := &WarningCollector{}
+
+	warnings.HandleWarningHeader(299, "", "example.com/v1alpha1 Widget is deprecated")
+	warnings.HandleWarningHeader(299, "", "example.com/v1alpha1 Widget is deprecated")
+	warnings.HandleWarningHeader(299, "", "policy/v1beta1 PodSecurityPolicy is deprecated in v1.21+, unavailable in v1.25+")
+	warnings.HandleWarningHeader(299, "", "widgets are going away soon")
+	warnings.HandleWarningHeader(199, "", "extensions/v1beta1 Ingress is deprecated in v1.14+")
+
+	assert.Equal(t, []api.Version{
+		{Name: "example.com/v1alpha1", Kind: "Widget", DeprecatedIn: "v1.25.0", Component: "k8s"},
+		{Name: "policy/v1beta1", Kind: "PodSecurityPolicy", DeprecatedIn: "v1.21.0", RemovedIn: "v1.25.0", Component: "k8s"},
+	}, warnings.DeprecatedVersions("v1.25.0"))
+	assert.Empty(t, warnings.DeprecatedVersions("v1.25.0"))
+
+	var none *WarningCollector
+	assert.Empty(t, none.DeprecatedVersions("v1.25.0"))
+}
@@ -0,0 +1,168 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kustomize
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/kustomize/api/konfig"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+
+	"github.com/fairwindsops/pluto/v5/pkg/api"
+)
+
+// Kustomize finds and builds the kustomizations in a directory
+type Kustomize struct {
+	RootPath string
+	// Kustomizations is the list of kustomization files that will be built.
+	// Kustomizations that are used as a base or component by another one are not included.
+	Kustomizations []string
+	Instance       *api.Instance
+}
+
+// kustomizationRefs holds the fields of a kustomization that can refer to other kustomizations
+type kustomizationRefs struct {
+	Resources  []string `yaml:"resources"`
+	Components []string `yaml:"components"`
+	Bases      []string `yaml:"bases"`
+}
+
+// NewKustomize returns a new struct with config portions complete.
+func NewKustomize(path string, instance *api.Instance) (*Kustomize, error) {
+	k := &Kustomize{
+		Instance: instance,
+	}
+	if path == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		k.RootPath = cwd
+	} else {
+		k.RootPath = path
+	}
+	return k, nil
+}
+
+// FindVersions builds every kustomization in the directory that is not used by
+// another one, and checks the rendered resources for versions. Findings are
+// attributed to the kustomization file that was built.
+func (k *Kustomize) FindVersions() error {
+	err := k.listKustomizations()
+	if err != nil {
+		return err
+	}
+	kustomizer := krusty.MakeKustomizer(krusty.MakeDefaultOptions())
+	fSys := filesys.MakeFsOnDisk()
+	for _, file := range k.Kustomizations {
+		klog.V(2).Infof("building kustomization %s", file)
+		resources, err := kustomizer.Run(fSys, filepath.Dir(file))
+		if err != nil {
+			klog.Errorf("error building kustomization %s: %s", file, err.Error())
+			continue
+		}
+		data, err := resources.AsYaml()
+		if err != nil {
+			return fmt.Errorf("error rendering kustomization %s: %w", file, err)
+		}
+		if err := k.Instance.AddCRDVersions(data); err != nil {
+			klog.V(2).Infof("error reading CustomResourceDefinitions in kustomization %s: %s", file, err.Error())
+		}
+		outputs, err := k.Instance.IsVersioned(data)
+		if err != nil {
+			return fmt.Errorf("error parsing kustomization %s: %w", file, err)
+		}
+		for _, output := range outputs {
+			output.FilePath = file
+		}
+		k.Instance.Outputs = append(k.Instance.Outputs, outputs...)
+	}
+	return nil
+}
+
+// listKustomizations finds the kustomization files under the root path, leaving out
+// the ones that are referred to by another kustomization
+func (k *Kustomize) listKustomizations() error {
+	if _, err := os.Stat(k.RootPath); os.IsNotExist(err) {
+		return fmt.Errorf("specified path does not exist")
+	}
+	var files []string
+	referenced := make(map[string]bool)
+	err := filepath.Walk(k.RootPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			klog.V(2).Infof("error walking path %s: %s", path, err.Error())
+			return nil
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !slices.Contains(konfig.RecognizedKustomizationFileNames(), info.Name()) {
+			return nil
+		}
+		files = append(files, path)
+		refs, err := readKustomizationRefs(path)
+		if err != nil {
+			klog.V(2).Infof("error reading kustomization %s: %s", path, err.Error())
+			return nil
+		}
+		for _, ref := range refs {
+			referenced[ref] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	k.Kustomizations = nil
+	for _, file := range files {
+		if referenced[filepath.Clean(filepath.Dir(file))] {
+			klog.V(3).Infof("skipping kustomization %s because it is used by another kustomization", file)
+			continue
+		}
+		k.Kustomizations = append(k.Kustomizations, file)
+	}
+	return nil
+}
+
+// readKustomizationRefs returns the local directories that a kustomization file uses
+func readKustomizationRefs(file string) ([]string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	refs := &kustomizationRefs{}
+	err = yaml.Unmarshal(data, refs)
+	if err != nil {
+		return nil, err
+	}
+	var dirs []string
+	for _, ref := range slices.Concat(refs.Resources, refs.Components, refs.Bases) {
+		dir := filepath.Clean(filepath.Join(filepath.Dir(file), ref))
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs, nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kustomize
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/fairwindsops/pluto/v5/pkg/api"
+)
+
+var testVersionDeployment = api.Version{
+	Name:           "extensions/v1beta1",
+	Kind:           "Deployment",
+	DeprecatedIn:   "v1.9.0",
+	RemovedIn:      "v1.16.0",
+	ReplacementAPI: "apps/v1",
+	Component:      "k8s",
+}
+
+func newMockKustomize(path string) *Kustomize {
+	return &Kustomize{
+		RootPath: path,
+		Instance: &api.Instance{
+			TargetVersions: map[string]string{
+				"k8s": "v1.16.0",
+			},
+			DeprecatedVersions: []api.Version{
+				testVersionDeployment,
+			},
+			OutputFormat: "normal",
+		},
+	}
+}
+
+func TestKustomize_listKustomizations(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "bases are skipped",
+			path: "testdata",
+			want: []string{
+				"testdata/overlays/prod/kustomization.yaml",
+				"testdata/standalone/kustomization.yaml",
+			},
+		},
+		{
+			name: "base on its own",
+			path: "testdata/base",
+			want: []string{"testdata/base/kustomization.yaml"},
+		},
+		{
+			name:    "path does not exist",
+			path:    "foo",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := newMockKustomize(tt.path)
+			err := k.listKustomizations()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, k.Kustomizations)
+		})
+	}
+}
+
+func TestKustomize_FindVersions(t *testing.T) {
+	k := newMockKustomize("testdata")
+	err := k.FindVersions()
+	assert.NoError(t, err)
+	assert.Equal(t, []*api.Output{
+		{
+			Name:       "prod-utilities",
+			Namespace:  "prod",
+			FilePath:   "testdata/overlays/prod/kustomization.yaml",
+			APIVersion: &testVersionDeployment,
+		},
+	}, k.Instance.Outputs)
+}
@@ -0,0 +1,123 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"k8s.io/klog/v2"
+
+	"github.com/fairwindsops/pluto/v5/pkg/api"
+)
+
+// Scanner runs a scan and returns the deprecated and removed resources that were found
+type Scanner func() ([]*api.Output, error)
+
+// Exporter runs a Scanner on an interval and exposes the results as Prometheus metrics
+type Exporter struct {
+	scan     Scanner
+	registry *prometheus.Registry
+
+	resources   *prometheus.GaugeVec
+	duration    prometheus.Gauge
+	lastSuccess prometheus.Gauge
+	scans       prometheus.Counter
+	errors      prometheus.Counter
+}
+
+// NewExporter returns an Exporter for a Scanner with all of its metrics registered
+func NewExporter(scan Scanner) *Exporter {
+	e := &Exporter{
+		scan:     scan,
+		registry: prometheus.NewRegistry(),
+		resources: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "pluto_deprecated_resources",
+			Help: "The number of resources using a deprecated or removed apiVersion, as of the last successful scan.",
+		}, []string{"kind", "api_version", "namespace", "component", "removed"}),
+		duration: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "pluto_scan_duration_seconds",
+			Help: "How long the last scan took.",
+		}),
+		lastSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "pluto_last_successful_scan_timestamp_seconds",
+			Help: "The time of the last successful scan as a unix timestamp.",
+		}),
+		scans: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "pluto_scans_total",
+			Help: "The number of scans that have been run.",
+		}),
+		errors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "pluto_scan_errors_total",
+			Help: "The number of scans that failed.",
+		}),
+	}
+	e.registry.MustRegister(e.resources, e.duration, e.lastSuccess, e.scans, e.errors)
+	return e
+}
+
+// Handler returns the http handler for the /metrics endpoint
+func (e *Exporter) Handler() http.Handler {
+	return promhttp.HandlerFor(e.registry, promhttp.HandlerOpts{})
+}
+
+// Scan runs a single scan and updates the metrics. If the scan fails, the resources
+// from the last successful scan are kept and the error counter is increased.
+func (e *Exporter) Scan() error {
+	start := time.Now()
+	outputs, err := e.scan()
+	e.duration.Set(time.Since(start).Seconds())
+	e.scans.Inc()
+	if err != nil {
+		e.errors.Inc()
+		return err
+	}
+
+	e.resources.Reset()
+	for _, output := range outputs {
+		if output.Suppressed {
+			continue
+		}
+		e.resources.WithLabelValues(
+			output.APIVersion.Kind,
+			output.APIVersion.Name,
+			output.Namespace,
+			output.APIVersion.Component,
+			strconv.FormatBool(output.Removed),
+		).Inc()
+	}
+	e.lastSuccess.SetToCurrentTime()
+	return nil
+}
+
+// Run scans immediately and then on every interval until stop is closed
+func (e *Exporter) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		klog.V(2).Info("starting scan")
+		if err := e.Scan(); err != nil {
+			klog.Errorf("scan failed: %s", err)
+		}
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/fairwindsops/pluto/v5/pkg/api"
+)
+
+var testVersionIngress = &api.Version{
+	Name:           "extensions/v1beta1",
+	Kind:           "Ingress",
+	DeprecatedIn:   "v1.14.0",
+	RemovedIn:      "v1.22.0",
+	ReplacementAPI: "networking.k8s.io/v1",
+	Component:      "k8s",
+}
+
+var testVersionPSP = &api.Version{
+	Name:         "policy/v1beta1",
+	Kind:         "PodSecurityPolicy",
+	DeprecatedIn: "v1.21.0",
+	RemovedIn:    "v1.25.0",
+	Component:    "k8s",
+}
+
+func TestExporter_Scan(t *testing.T) {
+	var outputs []*api.Output
+	var scanErr error
+	e := NewExporter(func() ([]*api.Output, error) {
+		return outputs, scanErr
+	})
+
+	outputs = []*api.Output{
+		{Name: "web", Namespace: "default", APIVersion: testVersionIngress, Deprecated: true, Removed: true},
+		{Name: "api", Namespace: "default", APIVersion: testVersionIngress, Deprecated: true, Removed: true},
+		{Name: "ignored", Namespace: "default", APIVersion: testVersionIngress, Deprecated: true, Removed: true, Suppressed: true},
+		{Name: "psp", APIVersion: testVersionPSP, Deprecated: true},
+	}
+	assert.NoError(t, e.Scan())
+	assert.NoError(t, testutil.CollectAndCompare(e.resources, strings.NewReader(`
+# HELP pluto_deprecated_resources The number of resources using a deprecated or removed apiVersion, as of the last successful scan.
+# TYPE pluto_deprecated_resources gauge
+pluto_deprecated_resources{api_version="extensions/v1beta1",component="k8s",kind="Ingress",namespace="default",removed="true"} 2
+pluto_deprecated_resources{api_version="policy/v1beta1",component="k8s",kind="PodSecurityPolicy",namespace="",removed="false"} 1
+`)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(e.scans))
+	assert.Equal(t, float64(0), testutil.ToFloat64(e.errors))
+	assert.NotZero(t, testutil.ToFloat64(e.lastSuccess))
+
+	// a failed scan keeps the resources from the last successful scan
+	scanErr = errors.New("connection refused")
+	assert.Error(t, e.Scan())
+	assert.Equal(t, 2, testutil.CollectAndCount(e.resources))
+	assert.Equal(t, float64(2), testutil.ToFloat64(e.scans))
+	assert.Equal(t, float64(1), testutil.ToFloat64(e.errors))
+
+	// resources that are fixed are removed
+	scanErr = nil
+	outputs = outputs[3:]
+	assert.NoError(t, e.Scan())
+	assert.Equal(t, 1, testutil.CollectAndCount(e.resources))
+}
+
+func TestExporter_Handler(t *testing.T) {
+	e := NewExporter(func() ([]*api.Output, error) {
+		return []*api.Output{{Name: "psp", APIVersion: testVersionPSP, Deprecated: true}}, nil
+	})
+	stop := make(chan struct{})
+	close(stop)
+	e.Run(time.Hour, stop)
+
+	recorder := httptest.NewRecorder()
+	e.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, 200, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `pluto_deprecated_resources{api_version="policy/v1beta1",component="k8s",kind="PodSecurityPolicy",namespace="",removed="false"} 1`)
+	assert.Contains(t, recorder.Body.String(), "pluto_scans_total 1")
+}
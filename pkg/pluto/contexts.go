@@ -0,0 +1,107 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluto
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"k8s.io/klog/v2"
+
+	"github.com/fairwindsops/pluto/v5/pkg/api"
+)
+
+// ContextError is the error of a kube context that could not be scanned
+type ContextError struct {
+	Context string
+	Err     error
+}
+
+// ContextsResult is the result of ScanContexts
+type ContextsResult struct {
+	// Failed are the kube contexts that could not be scanned, in the order they were passed
+	Failed []ContextError
+	// Interrupted is true if ctx was done before every kube context was scanned.
+	// The outputs that were found before then are still added.
+	Interrupted bool
+}
+
+// ReturnCode is the return code of instance, which is at least 1 if a kube
+// context failed or the scan was interrupted
+func (r ContextsResult) ReturnCode(instance *api.Instance) int {
+	returnCode := instance.GetReturnCode()
+	if (len(r.Failed) > 0 || r.Interrupted) && returnCode == 0 {
+		return 1
+	}
+	return returnCode
+}
+
+// ScanContexts scans the source that newSource returns for each of the kube contexts, concurrently.
+// Each context is scanned with its own copy of instance, and its outputs are added to instance
+// with the context as the cluster. A context that fails is added to the result and the others
+// are still scanned. An error is only returned if none of the contexts could be scanned.
+//
+// If contexts is empty, the source for the blank context, which is the current context, is
+// scanned into instance itself and the outputs have no cluster. Its error is returned.
+func ScanContexts(ctx context.Context, instance *api.Instance, contexts []string, newSource func(kubeContext string) Source) (ContextsResult, error) {
+	var result ContextsResult
+	if len(contexts) == 0 {
+		err := newSource("").Scan(ctx, instance)
+		if err != nil && ctx.Err() != nil {
+			result.Interrupted = true
+			return result, nil
+		}
+		return result, err
+	}
+
+	instances := make([]*api.Instance, len(contexts))
+	errs := make([]error, len(contexts))
+	var wg sync.WaitGroup
+	for i, c := range contexts {
+		copied := *instance
+		copied.Outputs = nil
+		copied.DeprecatedVersions = append([]api.Version{}, instance.DeprecatedVersions...)
+		instances[i] = &copied
+		source := newSource(c)
+		wg.Go(func() {
+			klog.V(2).Infof("scanning kube context %s", c)
+			errs[i] = source.Scan(ctx, instances[i])
+		})
+	}
+	wg.Wait()
+
+	scanned := false
+	for i, c := range contexts {
+		if errs[i] != nil {
+			if ctx.Err() == nil {
+				result.Failed = append(result.Failed, ContextError{Context: c, Err: errs[i]})
+				continue
+			}
+			result.Interrupted = true
+		} else {
+			scanned = true
+		}
+		for _, output := range instances[i].Outputs {
+			output.Cluster = c
+		}
+		instance.Outputs = append(instance.Outputs, instances[i].Outputs...)
+	}
+	if !scanned && !result.Interrupted {
+		return result, fmt.Errorf("could not scan any of the kube contexts %s", strings.Join(contexts, ", "))
+	}
+	return result, nil
+}
@@ -0,0 +1,204 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluto
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/fairwindsops/pluto/v5/pkg/api"
+)
+
+var removedWidget = api.Version{
+	Name:                   "example.com/v1alpha1",
+	Kind:                   "Widget",
+	DeprecatedIn:           "v1.0.0",
+	RemovedIn:              "v1.1.0",
+	ReplacementAPI:         "example.com/v1",
+	ReplacementAvailableIn: "v1.0.0",
+	Component:              "k8s",
+}
+
+// fakeContext is the outputs and the error that a fake source returns for a kube context
+type fakeContext struct {
+	names []string
+	err   error
+}
+
+func TestScanContexts(t *testing.T) {
+	contextErr := errors.New("connection refused")
+
+	tests := []struct {
+		name     string
+		contexts []string
+		fakes    map[string]fakeContext
+		// cancel cancels the context once a source has run
+		cancel         bool
+		want           []string
+		wantClusters   []string
+		wantFailed     []ContextError
+		wantErr        string
+		wantReturnCode int
+	}{
+		{
+			name:           "current context",
+			fakes:          map[string]fakeContext{"": {names: []string{"foo"}}},
+			want:           []string{"foo"},
+			wantClusters:   []string{""},
+			wantReturnCode: 3,
+		},
+		{
+			name:    "current context fails",
+			fakes:   map[string]fakeContext{"": {err: contextErr}},
+			wantErr: "connection refused",
+		},
+		{
+			name:           "current context interrupted",
+			fakes:          map[string]fakeContext{"": {names: []string{"foo"}, err: contextErr}},
+			cancel:         true,
+			want:           []string{"foo"},
+			wantClusters:   []string{""},
+			wantReturnCode: 3,
+		},
+		{
+			name:     "contexts",
+			contexts: []string{"staging", "production"},
+			fakes: map[string]fakeContext{
+				"staging":    {names: []string{"foo", "bar"}},
+				"production": {names: []string{"foo"}},
+			},
+			want:           []string{"foo", "bar", "foo"},
+			wantClusters:   []string{"staging", "staging", "production"},
+			wantReturnCode: 3,
+		},
+		{
+			name:     "no outputs",
+			contexts: []string{"staging", "production"},
+			fakes: map[string]fakeContext{
+				"staging":    {},
+				"production": {},
+			},
+			wantReturnCode: 0,
+		},
+		{
+			name:     "failing context",
+			contexts: []string{"staging", "production"},
+			fakes: map[string]fakeContext{
+				"staging":    {names: []string{"foo"}, err: contextErr},
+				"production": {},
+			},
+			wantFailed:     []ContextError{{Context: "staging", Err: contextErr}},
+			wantReturnCode: 1,
+		},
+		{
+			name:     "failing context keeps the others",
+			contexts: []string{"staging", "production"},
+			fakes: map[string]fakeContext{
+				"staging":    {err: contextErr},
+				"production": {names: []string{"foo"}},
+			},
+			want:           []string{"foo"},
+			wantClusters:   []string{"production"},
+			wantFailed:     []ContextError{{Context: "staging", Err: contextErr}},
+			wantReturnCode: 3,
+		},
+		{
+			name:     "every context fails",
+			contexts: []string{"staging", "production"},
+			fakes: map[string]fakeContext{
+				"staging":    {err: contextErr},
+				"production": {err: contextErr},
+			},
+			wantFailed: []ContextError{
+				{Context: "staging", Err: contextErr},
+				{Context: "production", Err: contextErr},
+			},
+			wantErr: "could not scan any of the kube contexts staging, production",
+		},
+		{
+			name:     "interrupted",
+			contexts: []string{"staging", "production"},
+			fakes: map[string]fakeContext{
+				"staging":    {names: []string{"foo"}, err: contextErr},
+				"production": {names: []string{"bar"}, err: contextErr},
+			},
+			cancel:         true,
+			want:           []string{"foo", "bar"},
+			wantClusters:   []string{"staging", "production"},
+			wantReturnCode: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			instance := &api.Instance{
+				TargetVersions:     map[string]string{"k8s": "v1.16.0"},
+				DeprecatedVersions: []api.Version{removedWidget},
+				Components:         []string{"k8s"},
+			}
+
+			result, err := ScanContexts(ctx, instance, tt.contexts, func(kubeContext string) Source {
+				fake := tt.fakes[kubeContext]
+				return SourceFunc(func(ctx context.Context, instance *api.Instance) error {
+					for _, name := range fake.names {
+						instance.Outputs = append(instance.Outputs, &api.Output{Name: name, APIVersion: &removedWidget})
+					}
+					if tt.cancel {
+						cancel()
+					}
+					return fake.err
+				})
+			})
+			assert.Equal(t, tt.wantFailed, result.Failed)
+			assert.Equal(t, tt.cancel, result.Interrupted)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			var names, clusters []string
+			for _, output := range instance.Outputs {
+				names = append(names, output.Name)
+				clusters = append(clusters, output.Cluster)
+			}
+			assert.Equal(t, tt.want, names)
+			assert.Equal(t, tt.wantClusters, clusters)
+			assert.Equal(t, tt.wantReturnCode, result.ReturnCode(instance))
+		})
+	}
+}
+
+func TestScanContexts_copiesInstance(t *testing.T) {
+	instance := &api.Instance{
+		TargetVersions:     map[string]string{"k8s": "v1.16.0"},
+		DeprecatedVersions: []api.Version{removedWidget},
+		Outputs:            []*api.Output{{Name: "existing", APIVersion: &removedWidget}},
+	}
+	_, err := ScanContexts(context.Background(), instance, []string{"staging"}, func(kubeContext string) Source {
+		return SourceFunc(func(ctx context.Context, copied *api.Instance) error {
+			assert.Empty(t, copied.Outputs)
+			copied.AddDeprecatedVersions([]api.Version{{Name: "example.com/v1beta1", Kind: "Widget", DeprecatedIn: "v1.0.0", Component: "k8s"}})
+			return nil
+		})
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []api.Version{removedWidget}, instance.DeprecatedVersions)
+	assert.Len(t, instance.Outputs, 1)
+}
@@ -0,0 +1,265 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pluto finds deprecated and removed Kubernetes apiVersions. It is the library
+// behind the pluto command line, for use in other Go programs:
+//
+//	report, err := pluto.Scan(ctx, pluto.Options{
+//		TargetVersions: map[string]string{"k8s": "v1.25.0"},
+//	}, pluto.Directory{Path: "manifests"})
+//	if err != nil {
+//		return err
+//	}
+//	err = report.Write(os.Stdout)
+//
+// Nothing in this package exits the process or writes to stdout.
+package pluto
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"golang.org/x/mod/semver"
+	"k8s.io/klog/v2"
+
+	plutoversionsfile "github.com/fairwindsops/pluto/v5"
+	"github.com/fairwindsops/pluto/v5/pkg/api"
+)
+
+// Options configures a scan. The zero value checks every component in the default
+// versions file against its default target version, and writes the normal output.
+type Options struct {
+	// VersionsFile is the data of the versions file to use. If nil, the versions
+	// file that is built into pluto is used.
+	VersionsFile []byte
+	// AdditionalVersions is the data of a versions file with more versions to check,
+	// like --additional-versions. It cannot contain any of the default versions.
+	AdditionalVersions []byte
+	// TargetVersions is the version to check against for each component. Components
+	// that are not set use the target versions from the versions files.
+	TargetVersions map[string]string
+	// Components limits the checks to these components. If empty, every component is checked.
+	Components []string
+	// Baseline is a list of known findings, which are reported separately and do not
+	// affect the return code
+	Baseline *api.Baseline
+
+	IgnoreDeprecations            bool
+	IgnoreRemovals                bool
+	IgnoreUnavailableReplacements bool
+	IgnoreTooNew                  bool
+	OnlyShowRemoved               bool
+
+	// OutputFormat is the format that Report.Write uses, one of OutputFormats.
+	// If blank, the normal format is used.
+	OutputFormat string
+	// Columns are the columns for the custom format, and optionally the markdown and csv formats
+	Columns []string
+	// NoHeaders leaves the headers out of the table formats
+	NoHeaders bool
+}
+
+// OutputFormats are the formats that a report can be written in
+var OutputFormats = []string{
+	"json",
+	"yaml",
+	"normal",
+	"wide",
+	"custom",
+	"markdown",
+	"csv",
+	"sarif",
+	"junit",
+}
+
+// Source is something that can be scanned for apiVersions. Scan adds the
+// outputs that it finds to instance.Outputs.
+type Source interface {
+	Scan(ctx context.Context, instance *api.Instance) error
+}
+
+// SourceFunc is a function that is a Source
+type SourceFunc func(ctx context.Context, instance *api.Instance) error
+
+// Scan calls f
+func (f SourceFunc) Scan(ctx context.Context, instance *api.Instance) error {
+	return f(ctx, instance)
+}
+
+// Scan scans each of the sources in order and returns a report of the
+// deprecated and removed apiVersions that were found. If ctx is done before
+// the scan is complete, the report of what was scanned so far is returned
+// with the error of ctx.
+func Scan(ctx context.Context, options Options, sources ...Source) (*Report, error) {
+	instance, err := NewInstance(options)
+	if err != nil {
+		return nil, err
+	}
+	for _, source := range sources {
+		if err := ctx.Err(); err != nil {
+			return NewReport(instance), err
+		}
+		if err := source.Scan(ctx, instance); err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return NewReport(instance), ctxErr
+			}
+			return nil, err
+		}
+	}
+	return NewReport(instance), nil
+}
+
+// NewInstance returns the instance that a scan with options uses. The versions files are
+// combined, and every component that is checked must have a valid target version.
+func NewInstance(options Options) (*api.Instance, error) {
+	versionsFile := options.VersionsFile
+	if versionsFile == nil {
+		versionsFile = plutoversionsfile.Content()
+	}
+	defaultVersions, defaultTargetVersions, err := api.GetDefaultVersionList(versionsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	deprecatedVersions := defaultVersions
+	if options.AdditionalVersions != nil {
+		if err := api.ValidateVersions(options.AdditionalVersions, defaultVersions); err != nil {
+			return nil, fmt.Errorf("invalid additional versions:\n%w", err)
+		}
+		additionalVersions, additionalTargetVersions, err := api.UnMarshalVersions(options.AdditionalVersions)
+		if err != nil {
+			return nil, err
+		}
+		deprecatedVersions, err = api.CombineAdditionalVersions(additionalVersions, defaultVersions)
+		if err != nil {
+			return nil, err
+		}
+		for c, v := range additionalTargetVersions {
+			klog.V(2).Infof("received target version from config: %s %s", c, v)
+			// Only add them to default target versions if they do not supersed any previously included
+			// This prevents overwriting the internal defaults
+			if _, found := defaultTargetVersions[c]; !found {
+				defaultTargetVersions[c] = v
+			}
+		}
+	}
+
+	// From the compiled list of deprecations and the components option, build a component list
+	var components []string
+	for _, v := range deprecatedVersions {
+		if api.StringInSlice(v.Component, components) {
+			continue
+		}
+		if len(options.Components) == 0 || api.StringInSlice(v.Component, options.Components) {
+			components = append(components, v.Component)
+		}
+	}
+	if len(components) < 1 {
+		return nil, fmt.Errorf("cannot find deprecations for zero components")
+	}
+
+	// Combine the default target versions and the ones that are passed. Ones that are passed in take precedence
+	targetVersions := make(map[string]string, len(defaultTargetVersions))
+	for k, v := range options.TargetVersions {
+		targetVersions[k] = v
+	}
+	for k, v := range defaultTargetVersions {
+		if _, found := targetVersions[k]; !found {
+			klog.V(2).Infof("assuming default targetVersion %s %s", k, v)
+			targetVersions[k] = v
+		}
+	}
+	for component, version := range targetVersions {
+		if !semver.IsValid(version) {
+			return nil, fmt.Errorf("you must use valid semver for all target versions with a leading 'v' - got %s %s", component, version)
+		}
+	}
+	for _, c := range components {
+		if _, found := targetVersions[c]; !found {
+			return nil, fmt.Errorf("you must pass a targetVersion for every component in the list - missing component: %s", c)
+		}
+	}
+
+	outputFormat := options.OutputFormat
+	if outputFormat == "" {
+		outputFormat = "normal"
+	}
+
+	return &api.Instance{
+		TargetVersions:                targetVersions,
+		OutputFormat:                  outputFormat,
+		CustomColumns:                 options.Columns,
+		IgnoreDeprecations:            options.IgnoreDeprecations,
+		IgnoreRemovals:                options.IgnoreRemovals,
+		IgnoreUnavailableReplacements: options.IgnoreUnavailableReplacements,
+		IgnoreTooNew:                  options.IgnoreTooNew,
+		OnlyShowRemoved:               options.OnlyShowRemoved,
+		NoHeaders:                     options.NoHeaders,
+		DeprecatedVersions:            deprecatedVersions,
+		Components:                    components,
+		Baseline:                      options.Baseline,
+	}, nil
+}
+
+// Report is the result of a scan
+type Report struct {
+	// Outputs are the deprecated and removed apiVersions that were found, without the baselined ones
+	Outputs []*api.Output
+	// Baselined are the outputs that are in the baseline
+	Baselined []*api.Output
+
+	instance *api.Instance
+}
+
+// NewReport returns the report for an instance that has been scanned
+func NewReport(instance *api.Instance) *Report {
+	filtered := *instance
+	filtered.Outputs = append([]*api.Output{}, instance.Outputs...)
+	filtered.Baselined = append([]*api.Output{}, instance.Baselined...)
+	filtered.FilterOutput()
+	return &Report{
+		Outputs:   filtered.Outputs,
+		Baselined: filtered.Baselined,
+		instance:  instance,
+	}
+}
+
+// ReturnCode is the exit code of the pluto command line for the report.
+// See api.Instance.GetReturnCode.
+func (r *Report) ReturnCode() int {
+	filtered := *r.instance
+	filtered.Outputs = r.Outputs
+	return filtered.GetReturnCode()
+}
+
+// Write writes the report to w in the output format of the scan
+func (r *Report) Write(w io.Writer) error {
+	if !api.StringInSlice(r.instance.OutputFormat, OutputFormats) {
+		return fmt.Errorf("output format must be one of %v - got %s", OutputFormats, r.instance.OutputFormat)
+	}
+	if r.instance.OutputFormat == "custom" && len(r.instance.CustomColumns) == 0 {
+		return fmt.Errorf("the custom output format requires columns")
+	}
+	for _, c := range r.instance.CustomColumns {
+		if !api.StringInSlice(c, api.PossibleColumnNames) {
+			return fmt.Errorf("invalid column %s - must be one of %v", c, api.PossibleColumnNames)
+		}
+	}
+	// WriteOutput filters the outputs, so write a copy to keep the report unchanged
+	written := *r.instance
+	written.Outputs = append([]*api.Output{}, r.instance.Outputs...)
+	written.Baselined = append([]*api.Output{}, r.instance.Baselined...)
+	return written.WriteOutput(w)
+}
@@ -0,0 +1,272 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluto
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/fairwindsops/pluto/v5/pkg/api"
+)
+
+var widget = []byte(`apiVersion: example.com/v1alpha1
+kind: Widget
+metadata:
+  name: gadget
+`)
+
+func TestScan(t *testing.T) {
+	additional, err := os.ReadFile("testdata/additional-versions.yaml")
+	assert.NoError(t, err)
+
+	report, err := Scan(context.Background(), Options{
+		AdditionalVersions: additional,
+		TargetVersions:     map[string]string{"k8s": "v1.16.0"},
+		OutputFormat:       "json",
+	}, Directory{Path: "testdata"}, Manifest{Data: widget})
+	assert.NoError(t, err)
+	if assert.Len(t, report.Outputs, 2) {
+		assert.Equal(t, "utilities", report.Outputs[0].Name)
+		assert.True(t, strings.HasSuffix(report.Outputs[0].FilePath, "testdata/deployment.yaml"))
+		assert.True(t, report.Outputs[0].Removed)
+		assert.Equal(t, "gadget", report.Outputs[1].Name)
+		assert.True(t, report.Outputs[1].Removed)
+	}
+	assert.Equal(t, 3, report.ReturnCode())
+
+	var out bytes.Buffer
+	assert.NoError(t, report.Write(&out))
+	var written api.Instance
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &written))
+	assert.Len(t, written.Outputs, 2)
+	assert.Equal(t, "v1.0.0", written.TargetVersions["custom"])
+
+	// writing does not change the report
+	assert.Len(t, report.Outputs, 2)
+	out.Reset()
+	assert.NoError(t, report.Write(&out))
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &written))
+	assert.Len(t, written.Outputs, 2)
+}
+
+func TestScan_components(t *testing.T) {
+	report, err := Scan(context.Background(), Options{
+		TargetVersions: map[string]string{"k8s": "v1.16.0"},
+		Components:     []string{"istio"},
+	}, File{Path: "testdata/deployment.yaml"})
+	assert.NoError(t, err)
+	assert.Empty(t, report.Outputs)
+	assert.Equal(t, 0, report.ReturnCode())
+
+	var out bytes.Buffer
+	assert.NoError(t, report.Write(&out))
+	assert.Equal(t, "No output to display\n", out.String())
+}
+
+func TestScan_baseline(t *testing.T) {
+	first, err := Scan(context.Background(), Options{
+		TargetVersions: map[string]string{"k8s": "v1.16.0"},
+	}, Directory{Path: "testdata"})
+	assert.NoError(t, err)
+
+	report, err := Scan(context.Background(), Options{
+		TargetVersions: map[string]string{"k8s": "v1.16.0"},
+		Baseline:       api.NewBaseline(first.Outputs),
+	}, Directory{Path: "testdata"})
+	assert.NoError(t, err)
+	assert.Empty(t, report.Outputs)
+	assert.Len(t, report.Baselined, 1)
+	assert.Equal(t, 0, report.ReturnCode())
+}
+
+func TestScan_errors(t *testing.T) {
+	sourceErr := errors.New("source failed")
+	called := false
+	failing := SourceFunc(func(ctx context.Context, instance *api.Instance) error {
+		return sourceErr
+	})
+	after := SourceFunc(func(ctx context.Context, instance *api.Instance) error {
+		called = true
+		return nil
+	})
+
+	_, err := Scan(context.Background(), Options{}, failing, after)
+	assert.ErrorIs(t, err, sourceErr)
+	assert.False(t, called)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report, err := Scan(ctx, Options{}, after)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, called)
+	assert.NotNil(t, report)
+	assert.Empty(t, report.Outputs)
+
+	_, err = Scan(context.Background(), Options{}, File{Path: "testdata/missing.yaml"})
+	assert.Error(t, err)
+}
+
+func TestScan_interrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	called := false
+	interrupted := SourceFunc(func(ctx context.Context, instance *api.Instance) error {
+		if err := (File{Path: "testdata/deployment.yaml"}).Scan(ctx, instance); err != nil {
+			return err
+		}
+		cancel()
+		return errors.New("list failed: context canceled")
+	})
+	after := SourceFunc(func(ctx context.Context, instance *api.Instance) error {
+		called = true
+		return nil
+	})
+
+	report, err := Scan(ctx, Options{
+		TargetVersions: map[string]string{"k8s": "v1.16.0"},
+	}, interrupted, after)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, called)
+	assert.Len(t, report.Outputs, 1)
+	assert.Equal(t, 3, report.ReturnCode())
+}
+
+func TestNewInstance(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		wantErr string
+		check   func(t *testing.T, instance *api.Instance)
+	}{
+		{
+			name: "defaults",
+			check: func(t *testing.T, instance *api.Instance) {
+				assert.Equal(t, "normal", instance.OutputFormat)
+				assert.Contains(t, instance.Components, "k8s")
+				assert.Contains(t, instance.TargetVersions, "k8s")
+				assert.NotEmpty(t, instance.DeprecatedVersions)
+			},
+		},
+		{
+			name: "target version overrides the default",
+			options: Options{
+				TargetVersions: map[string]string{"k8s": "v1.20.0"},
+				Components:     []string{"k8s"},
+			},
+			check: func(t *testing.T, instance *api.Instance) {
+				assert.Equal(t, "v1.20.0", instance.TargetVersions["k8s"])
+				assert.Equal(t, []string{"k8s"}, instance.Components)
+			},
+		},
+		{
+			name:    "target version is not semver",
+			options: Options{TargetVersions: map[string]string{"k8s": "1.20.0"}},
+			wantErr: "you must use valid semver for all target versions with a leading 'v' - got k8s 1.20.0",
+		},
+		{
+			name:    "unknown component",
+			options: Options{Components: []string{"foo"}},
+			wantErr: "cannot find deprecations for zero components",
+		},
+		{
+			name:    "invalid additional versions",
+			options: Options{AdditionalVersions: []byte("deprecated-versions:\n  - version: example.com/v1\n    kind: Widget\n    deprecated-in: 1.0.0\n    component: custom\n")},
+			wantErr: "invalid additional versions:\ndeprecated-versions[0] Widget example.com/v1: deprecated-in is not valid semver with a leading 'v' - got 1.0.0",
+		},
+		{
+			name:    "additional component without a target version",
+			options: Options{AdditionalVersions: []byte("deprecated-versions:\n  - version: example.com/v1\n    kind: Widget\n    deprecated-in: v1.0.0\n    component: custom\n")},
+			wantErr: "you must pass a targetVersion for every component in the list - missing component: custom",
+		},
+		{
+			name:    "invalid versions file",
+			options: Options{VersionsFile: []byte("deprecated-versions: foo")},
+			wantErr: "could not unmarshal versions file from data",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewInstance(tt.options)
+			if tt.wantErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tt.wantErr)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			tt.check(t, got)
+		})
+	}
+}
+
+func TestReport_Write(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "custom",
+			options: Options{OutputFormat: "custom", Columns: []string{"NAME", "VERSION"}},
+			want:    "NAME        VERSION             \nutilities   extensions/v1beta1  \n\n",
+		},
+		{
+			name:    "csv without headers",
+			options: Options{OutputFormat: "csv", Columns: []string{"NAME", "KIND"}, NoHeaders: true},
+			want:    "utilities,Deployment\n",
+		},
+		{
+			name:    "unknown format",
+			options: Options{OutputFormat: "xml"},
+			wantErr: "output format must be one of",
+		},
+		{
+			name:    "custom without columns",
+			options: Options{OutputFormat: "custom"},
+			wantErr: "the custom output format requires columns",
+		},
+		{
+			name:    "unknown column",
+			options: Options{OutputFormat: "custom", Columns: []string{"COLOR"}},
+			wantErr: "invalid column COLOR",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.options.TargetVersions = map[string]string{"k8s": "v1.16.0"}
+			report, err := Scan(context.Background(), tt.options, File{Path: "testdata/deployment.yaml"})
+			assert.NoError(t, err)
+
+			var out bytes.Buffer
+			err = report.Write(&out)
+			if tt.wantErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tt.wantErr)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluto
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"k8s.io/klog/v2"
+
+	"github.com/fairwindsops/pluto/v5/pkg/api"
+	"github.com/fairwindsops/pluto/v5/pkg/audit"
+	discoveryapi "github.com/fairwindsops/pluto/v5/pkg/discovery-api"
+	"github.com/fairwindsops/pluto/v5/pkg/finder"
+	"github.com/fairwindsops/pluto/v5/pkg/helm"
+	"github.com/fairwindsops/pluto/v5/pkg/kustomize"
+)
+
+// Directory is the files in a directory, like detect-files
+type Directory struct {
+	// Path is the directory to scan. If blank, the working directory is scanned.
+	Path string
+	// Workers is the number of files to scan concurrently
+	Workers int
+	// Include is a list of gitignore-style patterns. If set, only files matching one are scanned.
+	Include []string
+	// Exclude is a list of gitignore-style patterns for paths that should not be scanned
+	Exclude []string
+}
+
+// Scan scans the files in the directory
+func (d Directory) Scan(ctx context.Context, instance *api.Instance) error {
+	dir, err := finder.NewFinder(d.Path, instance)
+	if err != nil {
+		return err
+	}
+	dir.Workers = d.Workers
+	dir.Include = d.Include
+	dir.Exclude = d.Exclude
+	return dir.FindVersions()
+}
+
+// File is a single file, like detect
+type File struct {
+	Path string
+}
+
+// Scan scans the file
+func (f File) Scan(ctx context.Context, instance *api.Instance) error {
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		return err
+	}
+	if err := instance.AddCRDVersions(data); err != nil {
+		klog.V(2).Infof("error reading CustomResourceDefinitions: %s", err)
+	}
+	dir := finder.Dir{
+		Instance: instance,
+	}
+	outputs, err := dir.CheckForAPIVersion(f.Path)
+	if err != nil {
+		return err
+	}
+	instance.Outputs = append(instance.Outputs, outputs...)
+	return nil
+}
+
+// Manifest is yaml or json data with one or more manifests
+type Manifest struct {
+	Data []byte
+}
+
+// Scan scans the manifests
+func (m Manifest) Scan(ctx context.Context, instance *api.Instance) error {
+	if err := instance.AddCRDVersions(m.Data); err != nil {
+		klog.V(2).Infof("error reading CustomResourceDefinitions: %s", err)
+	}
+	outputs, err := instance.IsVersioned(m.Data)
+	if err != nil {
+		return err
+	}
+	instance.Outputs = append(instance.Outputs, outputs...)
+	return nil
+}
+
+// Kustomize is the rendered kustomizations in a directory, like detect-kustomize
+type Kustomize struct {
+	// Path is the directory to scan. If blank, the working directory is scanned.
+	Path string
+}
+
+// Scan builds and scans the kustomizations
+func (k Kustomize) Scan(ctx context.Context, instance *api.Instance) error {
+	kustomization, err := kustomize.NewKustomize(k.Path, instance)
+	if err != nil {
+		return err
+	}
+	return kustomization.FindVersions()
+}
+
+// HelmChart is a local chart, like detect-helm-chart
+type HelmChart struct {
+	// Path is a chart directory or archive
+	Path string
+	// Namespace is used when rendering the chart
+	Namespace string
+	// Options are the values and capabilities used when rendering the chart. If
+	// Options.KubeVersion is blank, the k8s target version is used.
+	Options helm.ChartOptions
+}
+
+// Scan renders and scans the chart
+func (c HelmChart) Scan(ctx context.Context, instance *api.Instance) error {
+	options := c.Options
+	if options.KubeVersion == "" {
+		options.KubeVersion = instance.TargetVersions["k8s"]
+	}
+	return helm.NewHelmForChart(c.Namespace, instance).FindChartVersions(c.Path, options)
+}
+
+// AuditLog is a Kubernetes audit log, like detect-audit-log
+type AuditLog struct {
+	Path string
+}
+
+// Scan reads the audit events in the log
+func (a AuditLog) Scan(ctx context.Context, instance *api.Instance) error {
+	return audit.NewAuditLog(a.Path, instance).FindVersions()
+}
+
+// Cluster is the kube context of a cluster to scan
+type Cluster struct {
+	// KubeContext is the context to use. If blank, the current context is used.
+	KubeContext string
+	// KubeConfigPath is the kubeconfig file to use. If blank, the default kubeconfig is used.
+	KubeConfigPath string
+	// Namespace limits the scan to a namespace. If blank, every namespace is scanned.
+	Namespace string
+}
+
+// HelmReleases is the Helm releases in a cluster, like detect-helm
+type HelmReleases struct {
+	Cluster
+}
+
+// Scan scans the deployed releases
+func (h HelmReleases) Scan(ctx context.Context, instance *api.Instance) error {
+	releases, err := helm.NewHelm(ctx, h.Namespace, h.KubeContext, instance, h.KubeConfigPath)
+	if err != nil {
+		return fmt.Errorf("error getting helm configuration: %w", err)
+	}
+	if err := releases.FindVersions(ctx); err != nil {
+		return fmt.Errorf("error scanning helm releases: %w", err)
+	}
+	return nil
+}
+
+// APIResources is the resources in a cluster, like detect-api-resources
+type APIResources struct {
+	Cluster
+}
+
+// Scan scans every resource that the cluster serves
+func (a APIResources) Scan(ctx context.Context, instance *api.Instance) error {
+	client, err := discoveryapi.NewDiscoveryClient(ctx, a.Namespace, a.KubeContext, instance, a.KubeConfigPath)
+	if err != nil {
+		return fmt.Errorf("error creating discovery client: %w", err)
+	}
+	if err := client.GetApiResources(ctx); err != nil {
+		return fmt.Errorf("error getting API resources using discovery client: %w", err)
+	}
+	return nil
+}
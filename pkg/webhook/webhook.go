@@ -0,0 +1,149 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/fairwindsops/pluto/v5/pkg/api"
+)
+
+// Webhook is a validating admission webhook that checks the apiVersion of incoming objects.
+// Objects with an apiVersion that is removed in the target version are denied, and objects
+// with an apiVersion that is deprecated are allowed with a warning.
+type Webhook struct {
+	Instance *api.Instance
+	// WarnOnly allows objects with a removed apiVersion, with a warning
+	WarnOnly bool
+	// DenyDeprecated denies objects with a deprecated apiVersion too
+	DenyDeprecated bool
+}
+
+// NewWebhook returns a webhook that checks objects against the versions in instance
+func NewWebhook(instance *api.Instance) *Webhook {
+	return &Webhook{
+		Instance: instance,
+	}
+}
+
+// ServeHTTP handles an admission.k8s.io/v1 AdmissionReview
+func (w *Webhook) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(rw, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	review := &admissionv1.AdmissionReview{}
+	err := json.NewDecoder(req.Body).Decode(review)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("could not decode AdmissionReview: %s", err), http.StatusBadRequest)
+		return
+	}
+	if review.Request == nil {
+		http.Error(rw, "AdmissionReview has no request", http.StatusBadRequest)
+		return
+	}
+
+	response := &admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: admissionv1.SchemeGroupVersion.String(),
+			Kind:       "AdmissionReview",
+		},
+		Response: w.Review(review.Request),
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(rw).Encode(response)
+	if err != nil {
+		klog.Errorf("error writing AdmissionReview response: %s", err)
+	}
+}
+
+// Review checks the object in an admission request. The apiVersion and kind that the
+// request was made with are checked, since the object may have been converted to
+// another version before it was sent to the webhook.
+func (w *Webhook) Review(request *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	response := &admissionv1.AdmissionResponse{
+		UID:     request.UID,
+		Allowed: true,
+	}
+	if len(request.Object.Raw) == 0 {
+		return response
+	}
+
+	var object map[string]any
+	err := json.Unmarshal(request.Object.Raw, &object)
+	if err != nil {
+		klog.Errorf("could not decode object %s/%s: %s", request.Namespace, request.Name, err)
+		return response
+	}
+	kind := request.RequestKind
+	if kind == nil {
+		kind = &request.Kind
+	}
+	object["apiVersion"] = metav1.GroupVersion{Group: kind.Group, Version: kind.Version}.String()
+	object["kind"] = kind.Kind
+	data, err := json.Marshal(object)
+	if err != nil {
+		klog.Errorf("could not encode object %s/%s: %s", request.Namespace, request.Name, err)
+		return response
+	}
+	outputs, err := w.Instance.IsVersioned(data)
+	if err != nil {
+		klog.Errorf("could not check object %s/%s: %s", request.Namespace, request.Name, err)
+		return response
+	}
+
+	var denied []string
+	for _, output := range outputs {
+		if output.Suppressed || !api.StringInSlice(output.APIVersion.Component, w.Instance.Components) {
+			continue
+		}
+		w.Instance.SetOutputStatus(output)
+		var message string
+		switch {
+		case output.Removed:
+			message = fmt.Sprintf("%s %s is removed in %s", output.APIVersion.Name, output.APIVersion.Kind, output.APIVersion.RemovedIn)
+		case output.Deprecated:
+			message = fmt.Sprintf("%s %s is deprecated in %s", output.APIVersion.Name, output.APIVersion.Kind, output.APIVersion.DeprecatedIn)
+		default:
+			continue
+		}
+		if output.APIVersion.ReplacementAPI != "" {
+			message = fmt.Sprintf("%s; use %s", message, output.APIVersion.ReplacementAPI)
+		}
+		klog.V(2).Infof("%s %s/%s: %s", request.Operation, request.Namespace, request.Name, message)
+		if !w.WarnOnly && (output.Removed || w.DenyDeprecated) {
+			denied = append(denied, message)
+		} else {
+			response.Warnings = append(response.Warnings, message)
+		}
+	}
+	if len(denied) > 0 {
+		response.Allowed = false
+		response.Result = &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Code:    http.StatusForbidden,
+			Reason:  metav1.StatusReasonForbidden,
+			Message: strings.Join(denied, ", "),
+		}
+	}
+	return response
+}
@@ -0,0 +1,254 @@
+// Copyright 2022 FairwindsOps Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/fairwindsops/pluto/v5/pkg/api"
+)
+
+var testVersions = []api.Version{
+	{
+		Name:           "extensions/v1beta1",
+		Kind:           "Ingress",
+		DeprecatedIn:   "v1.14.0",
+		RemovedIn:      "v1.22.0",
+		ReplacementAPI: "networking.k8s.io/v1",
+		Component:      "k8s",
+	},
+	{
+		Name:         "policy/v1beta1",
+		Kind:         "PodSecurityPolicy",
+		DeprecatedIn: "v1.21.0",
+		RemovedIn:    "v1.25.0",
+		Component:    "k8s",
+	},
+	{
+		Name:         "cert-manager.io/v1alpha2",
+		Kind:         "Certificate",
+		DeprecatedIn: "v1.4.0",
+		RemovedIn:    "v1.6.0",
+		Component:    "cert-manager",
+	},
+}
+
+func newTestWebhook(target string) *Webhook {
+	return NewWebhook(&api.Instance{
+		TargetVersions:     map[string]string{"k8s": target, "cert-manager": "v1.6.0"},
+		DeprecatedVersions: testVersions,
+		Components:         []string{"k8s"},
+	})
+}
+
+func newTestRequest(group string, version string, kind string, annotations map[string]string) *admissionv1.AdmissionRequest {
+	object := map[string]any{
+		"apiVersion": metav1.GroupVersion{Group: group, Version: version}.String(),
+		"kind":       kind,
+		"metadata": map[string]any{
+			"name":        "web",
+			"namespace":   "default",
+			"annotations": annotations,
+		},
+	}
+	raw, _ := json.Marshal(object)
+	return &admissionv1.AdmissionRequest{
+		UID:       "1234",
+		Kind:      metav1.GroupVersionKind{Group: group, Version: version, Kind: kind},
+		Name:      "web",
+		Namespace: "default",
+		Operation: admissionv1.Create,
+		Object:    runtime.RawExtension{Raw: raw},
+	}
+}
+
+func TestWebhook_Review(t *testing.T) {
+	converted := newTestRequest("networking.k8s.io", "v1", "Ingress", nil)
+	converted.RequestKind = &metav1.GroupVersionKind{Group: "extensions", Version: "v1beta1", Kind: "Ingress"}
+
+	deleted := newTestRequest("extensions", "v1beta1", "Ingress", nil)
+	deleted.Operation = admissionv1.Delete
+	deleted.Object = runtime.RawExtension{}
+
+	tests := []struct {
+		name           string
+		target         string
+		warnOnly       bool
+		denyDeprecated bool
+		request        *admissionv1.AdmissionRequest
+		wantAllowed    bool
+		wantMessage    string
+		wantWarnings   []string
+	}{
+		{
+			name:        "removed is denied",
+			target:      "v1.22.0",
+			request:     newTestRequest("extensions", "v1beta1", "Ingress", nil),
+			wantAllowed: false,
+			wantMessage: "extensions/v1beta1 Ingress is removed in v1.22.0; use networking.k8s.io/v1",
+		},
+		{
+			name:         "removed with warn only",
+			target:       "v1.22.0",
+			warnOnly:     true,
+			request:      newTestRequest("extensions", "v1beta1", "Ingress", nil),
+			wantAllowed:  true,
+			wantWarnings: []string{"extensions/v1beta1 Ingress is removed in v1.22.0; use networking.k8s.io/v1"},
+		},
+		{
+			name:         "deprecated is warned",
+			target:       "v1.21.0",
+			request:      newTestRequest("policy", "v1beta1", "PodSecurityPolicy", nil),
+			wantAllowed:  true,
+			wantWarnings: []string{"policy/v1beta1 PodSecurityPolicy is deprecated in v1.21.0"},
+		},
+		{
+			name:           "deprecated with deny deprecated",
+			target:         "v1.21.0",
+			denyDeprecated: true,
+			request:        newTestRequest("policy", "v1beta1", "PodSecurityPolicy", nil),
+			wantAllowed:    false,
+			wantMessage:    "policy/v1beta1 PodSecurityPolicy is deprecated in v1.21.0",
+		},
+		{
+			name:        "not yet deprecated",
+			target:      "v1.20.0",
+			request:     newTestRequest("policy", "v1beta1", "PodSecurityPolicy", nil),
+			wantAllowed: true,
+		},
+		{
+			name:        "not versioned",
+			target:      "v1.22.0",
+			request:     newTestRequest("apps", "v1", "Deployment", nil),
+			wantAllowed: true,
+		},
+		{
+			name:        "suppressed",
+			target:      "v1.22.0",
+			request:     newTestRequest("extensions", "v1beta1", "Ingress", map[string]string{api.IgnoreAnnotation: "true"}),
+			wantAllowed: true,
+		},
+		{
+			name:        "component not checked",
+			target:      "v1.22.0",
+			request:     newTestRequest("cert-manager.io", "v1alpha2", "Certificate", nil),
+			wantAllowed: true,
+		},
+		{
+			name:        "request kind of a converted object",
+			target:      "v1.22.0",
+			request:     converted,
+			wantAllowed: false,
+			wantMessage: "extensions/v1beta1 Ingress is removed in v1.22.0; use networking.k8s.io/v1",
+		},
+		{
+			name:        "no object",
+			target:      "v1.22.0",
+			request:     deleted,
+			wantAllowed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newTestWebhook(tt.target)
+			w.WarnOnly = tt.warnOnly
+			w.DenyDeprecated = tt.denyDeprecated
+
+			got := w.Review(tt.request)
+			assert.EqualValues(t, tt.request.UID, got.UID)
+			assert.Equal(t, tt.wantAllowed, got.Allowed)
+			assert.Equal(t, tt.wantWarnings, got.Warnings)
+			if tt.wantAllowed {
+				assert.Nil(t, got.Result)
+				return
+			}
+			if assert.NotNil(t, got.Result) {
+				assert.Equal(t, tt.wantMessage, got.Result.Message)
+				assert.EqualValues(t, http.StatusForbidden, got.Result.Code)
+				assert.Equal(t, metav1.StatusReasonForbidden, got.Result.Reason)
+			}
+		})
+	}
+}
+
+func TestWebhook_ServeHTTP(t *testing.T) {
+	review, err := os.ReadFile("testdata/ingress-review.json")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		method      string
+		body        []byte
+		wantCode    int
+		wantAllowed bool
+		wantMessage string
+	}{
+		{
+			name:        "canned review",
+			method:      http.MethodPost,
+			body:        review,
+			wantCode:    http.StatusOK,
+			wantAllowed: false,
+			wantMessage: "extensions/v1beta1 Ingress is removed in v1.22.0; use networking.k8s.io/v1",
+		},
+		{
+			name:     "wrong method",
+			method:   http.MethodGet,
+			wantCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:     "bad body",
+			method:   http.MethodPost,
+			body:     []byte("not json"),
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "no request",
+			method:   http.MethodPost,
+			body:     []byte(`{"apiVersion":"admission.k8s.io/v1","kind":"AdmissionReview"}`),
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			newTestWebhook("v1.22.0").ServeHTTP(recorder, httptest.NewRequest(tt.method, "/validate", bytes.NewReader(tt.body)))
+			assert.Equal(t, tt.wantCode, recorder.Code)
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			got := &admissionv1.AdmissionReview{}
+			assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), got))
+			assert.Equal(t, "admission.k8s.io/v1", got.APIVersion)
+			assert.Equal(t, "AdmissionReview", got.Kind)
+			if assert.NotNil(t, got.Response) {
+				assert.EqualValues(t, "705ab4f5-6393-11e8-b7cc-42010a800002", got.Response.UID)
+				assert.Equal(t, tt.wantAllowed, got.Response.Allowed)
+				assert.Equal(t, tt.wantMessage, got.Response.Result.Message)
+			}
+		})
+	}
+}